@@ -0,0 +1,213 @@
+package analyze
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"golang.org/x/net/html"
+)
+
+const (
+	_wcagNonTextContent     = "1.1.1 Non-text Content"
+	_wcagInfoRelationships  = "1.3.1 Info and Relationships"
+	_wcagLinkPurpose        = "2.4.4 Link Purpose (In Context)"
+	_wcagLanguageOfPage     = "3.1.1 Language of Page"
+	_wcagLabelsInstructions = "3.3.2 Labels or Instructions"
+)
+
+type accessibilityCandidate struct {
+	finding models.AccessibilityFinding
+	// labelID is the id of an unlabeled field, which is fine if a <label for>
+	// anywhere in the document points to it.
+	labelID string
+}
+
+type accessibilityCollector struct {
+	candidates   []accessibilityCandidate
+	labelFors    map[string]bool
+	mains        []*html.Node
+	headingLevel int
+	htmlVisited  bool
+	htmlHasLang  bool
+	htmlPath     string
+}
+
+// Accessibility checks the document against a subset of the WCAG success
+// criteria. Every finding points to the offending node with a CSS-like path.
+func (a *Analyzer) Accessibility(n *html.Node) bool {
+	if n.Type != html.ElementNode || n.Namespace != "" {
+		return false
+	}
+	if a.accessibility == nil {
+		a.accessibility = &accessibilityCollector{labelFors: make(map[string]bool)}
+	}
+	c := a.accessibility
+	add := func(code string, severity models.Severity, wcag, message string) {
+		c.candidates = append(c.candidates, accessibilityCandidate{finding: models.AccessibilityFinding{
+			Finding: models.Finding{Code: code, Severity: severity, Message: message},
+			Path:    cssPath(n),
+			WCAG:    wcag,
+		}})
+	}
+
+	switch n.Data {
+	case "html":
+		c.htmlVisited = true
+		c.htmlHasLang = strings.TrimSpace(attribute(n, "lang")) != "" || strings.TrimSpace(attribute(n, "xml:lang")) != ""
+		c.htmlPath = cssPath(n)
+	case "img":
+		if !hasAttribute(n, "alt") && !isPresentational(n) && !hasAccessibleName(n) {
+			add("image_missing_alt", models.SeverityError, _wcagNonTextContent, "the image has no alt attribute")
+		}
+	case "input", "select", "textarea":
+		inputType := strings.ToLower(attribute(n, "type"))
+		switch inputType {
+		case "hidden", "submit", "button", "reset":
+			return false
+		case "image":
+			if !hasAttribute(n, "alt") && !hasAccessibleName(n) {
+				add("image_missing_alt", models.SeverityError, _wcagNonTextContent, "the image button has no alt attribute")
+			}
+			return true
+		}
+		if hasAccessibleName(n) || hasAncestor(n, "label") {
+			return true
+		}
+		add("input_missing_label", models.SeverityError, _wcagLabelsInstructions, fmt.Sprintf("the %s has no associated label", n.Data))
+		c.candidates[len(c.candidates)-1].labelID = attribute(n, "id")
+	case "label":
+		if id := attribute(n, "for"); id != "" {
+			c.labelFors[id] = true
+		}
+	case "a":
+		if !hasAttribute(n, "href") {
+			return false
+		}
+		if !hasAccessibleName(n) && !hasDiscernibleText(n) {
+			add("link_missing_text", models.SeverityError, _wcagLinkPurpose, "the link has no discernible text")
+		}
+	case "main":
+		c.mains = append(c.mains, n)
+	case "h1", "h2", "h3", "h4", "h5", "h6":
+		level := int(n.Data[1] - '0')
+		if finding, ok := headingLevelSkipped(c.headingLevel, level); ok {
+			add(finding.Code, finding.Severity, _wcagInfoRelationships, finding.Message)
+		}
+		c.headingLevel = level
+	default:
+		return false
+	}
+	return true
+}
+
+func (a *Analyzer) collectAccessibility() {
+	c := a.accessibility
+	if c == nil {
+		return
+	}
+	findings := make([]models.AccessibilityFinding, 0, len(c.candidates))
+	if c.htmlVisited && !c.htmlHasLang {
+		findings = append(findings, models.AccessibilityFinding{
+			Finding: models.Finding{Code: "html_missing_lang", Severity: models.SeverityError, Message: "the html element has no lang attribute"},
+			Path:    c.htmlPath,
+			WCAG:    _wcagLanguageOfPage,
+		})
+	}
+	for _, candidate := range c.candidates {
+		if candidate.labelID != "" && c.labelFors[candidate.labelID] {
+			continue
+		}
+		findings = append(findings, candidate.finding)
+	}
+	if len(c.mains) > 1 {
+		for _, main := range c.mains[1:] {
+			findings = append(findings, models.AccessibilityFinding{
+				Finding: models.Finding{Code: "multiple_main", Severity: models.SeverityWarning, Message: fmt.Sprintf("the page has %d main landmarks", len(c.mains))},
+				Path:    cssPath(main),
+				WCAG:    _wcagInfoRelationships,
+			})
+		}
+	}
+	a.result.Accessibility = findings
+}
+
+func hasAccessibleName(n *html.Node) bool {
+	for _, key := range []string{"aria-label", "aria-labelledby", "title"} {
+		if strings.TrimSpace(attribute(n, key)) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+func isPresentational(n *html.Node) bool {
+	role := strings.ToLower(attribute(n, "role"))
+	return role == "presentation" || role == "none" || attribute(n, "aria-hidden") == "true"
+}
+
+// hasDiscernibleText reports whether n contains text or an image with a
+// text alternative.
+func hasDiscernibleText(n *html.Node) bool {
+	if strings.TrimSpace(textContent(n)) != "" {
+		return true
+	}
+	var found bool
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		if found {
+			return
+		}
+		if n.Type == html.ElementNode && (n.Data == "img" || n.Data == "svg") &&
+			(strings.TrimSpace(attribute(n, "alt")) != "" || hasAccessibleName(n)) {
+			found = true
+			return
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(n)
+	return found
+}
+
+func hasAncestor(n *html.Node, tag string) bool {
+	for p := n.Parent; p != nil; p = p.Parent {
+		if p.Type == html.ElementNode && p.Data == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// cssPath returns a selector like "body > div:nth-of-type(2) > img", which
+// starts at the closest ancestor with an id when there is one.
+func cssPath(n *html.Node) string {
+	var segments []string
+	for node := n; node != nil && node.Type == html.ElementNode; node = node.Parent {
+		if id := attribute(node, "id"); id != "" {
+			segments = append(segments, node.Data+"#"+id)
+			break
+		}
+		segment := node.Data
+		var index, total int
+		if node.Parent != nil {
+			for sibling := node.Parent.FirstChild; sibling != nil; sibling = sibling.NextSibling {
+				if sibling.Type == html.ElementNode && sibling.Data == node.Data {
+					total++
+					if sibling == node {
+						index = total
+					}
+				}
+			}
+		}
+		if total > 1 {
+			segment += fmt.Sprintf(":nth-of-type(%d)", index)
+		}
+		segments = append(segments, segment)
+	}
+	for i, j := 0, len(segments)-1; i < j; i, j = i+1, j-1 {
+		segments[i], segments[j] = segments[j], segments[i]
+	}
+	return strings.Join(segments, " > ")
+}
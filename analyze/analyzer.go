@@ -1,8 +1,11 @@
 package analyze
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
 	"strings"
@@ -10,6 +13,10 @@ import (
 	"time"
 
 	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"github.com/danielperaltamadriz/html-analyzer/cache"
+	"github.com/danielperaltamadriz/html-analyzer/internal/neterr"
+	"github.com/danielperaltamadriz/html-analyzer/netguard"
+	"github.com/danielperaltamadriz/html-analyzer/robots"
 	"golang.org/x/net/html"
 )
 
@@ -19,25 +26,54 @@ const (
 
 type SearchElement func(n *html.Node) bool
 type Analyzer struct {
-	result models.HTMLDetails
-	url    string
-	ctx    context.Context
-	req    *http.Request
-	node   *html.Node
+	result      models.HTMLDetails
+	url         string
+	pageURL     *url.URL
+	baseURL     *url.URL
+	baseApplied bool
+	ctx         context.Context
+	node        *html.Node
 
 	searchSingleElements []SearchElement
 	singleSearchesDone   map[int]bool
 	searchManyElements   []SearchElement
+	rules                []Rule
+	rulesDone            map[int]bool
+	seo                  *seoCollector
+	accessibility        *accessibilityCollector
+	outline              []*models.HeadingNode
+	headingsSearched     bool
 
-	verifyLinkFunc func(l *models.Link) bool
+	client    *http.Client
+	header    http.Header
+	userAgent string
+	jar       http.CookieJar
+
+	robots              *robots.Cache
+	skipDisallowedLinks bool
+
+	results     *cache.Results
+	links       *cache.Links
+	cacheBypass bool
+	cacheKey    string
+	cached      *cache.Result
+	page        *page
+
+	linkVerifier LinkVerifier
+	progressFunc func(verified, total int)
 }
 
 func NewAnalyzer() *Analyzer {
 	return &Analyzer{
 		singleSearchesDone: make(map[int]bool),
+		rulesDone:          make(map[int]bool),
 	}
 }
 
+func (a *Analyzer) WithContext(ctx context.Context) {
+	a.ctx = ctx
+}
+
 func (a *Analyzer) WithSearchSingleElements(searchElements ...SearchElement) {
 	a.searchSingleElements = searchElements
 }
@@ -49,6 +85,7 @@ func (a *Analyzer) WithSearchManyElements(searchElements ...SearchElement) {
 func (a *Analyzer) run(node *html.Node) models.HTMLDetails {
 	var f func(*html.Node) bool
 	f = func(n *html.Node) bool {
+		a.base(n)
 		for i, searchElement := range a.searchSingleElements {
 			if a.singleSearchesDone[i] {
 				continue
@@ -57,7 +94,9 @@ func (a *Analyzer) run(node *html.Node) models.HTMLDetails {
 				a.singleSearchesDone[i] = true
 			}
 		}
-		if len(a.singleSearchesDone) == len(a.searchSingleElements) && len(a.searchManyElements) == 0 {
+		a.visitRules(n)
+		if len(a.singleSearchesDone) == len(a.searchSingleElements) && len(a.searchManyElements) == 0 &&
+			len(a.rulesDone) == len(a.rules) {
 			return true
 		}
 		for _, searchElement := range a.searchManyElements {
@@ -72,63 +111,159 @@ func (a *Analyzer) run(node *html.Node) models.HTMLDetails {
 		return false
 	}
 	f(node)
+	a.collectRules()
+	a.collectHeadingOutline()
+	a.collectSEO()
+	a.collectAccessibility()
 	a.verifyLinks()
 	return a.result
 }
 
 func (a *Analyzer) RunFromURL(url string) (*models.HTMLDetails, error) {
 	a.url = url
+	if details, ok := a.cachedResult(); ok {
+		return details, nil
+	}
 	if err := a.requestHTML(); err != nil {
 		return nil, err
 	}
 	details := a.run(a.node)
+	a.storeResult(&details)
+	return &details, nil
+}
+
+func (a *Analyzer) RunFromReader(ctx context.Context, r io.Reader, baseURL string) (*models.HTMLDetails, error) {
+	doc, err := a.parseHTML(r, "")
+	if err != nil {
+		return nil, err
+	}
+	return a.RunFromNode(ctx, doc, baseURL)
+}
+
+func (a *Analyzer) RunFromNode(ctx context.Context, node *html.Node, baseURL string) (*models.HTMLDetails, error) {
+	if node == nil {
+		return nil, models.NewError(models.ErrTypeInvalidResponse, "empty html node")
+	}
+	if baseURL != "" {
+		base, err := url.Parse(baseURL)
+		if err != nil {
+			return nil, models.NewError(models.ErrTypeInvalidURL, "invalid base url")
+		}
+		a.pageURL = base
+		a.baseURL = base
+	}
+	a.ctx = ctx
+	a.node = node
+	details := a.run(node)
 	return &details, nil
 }
 
 func (a *Analyzer) requestHTML() error {
-	ctx := a.ctx
-	if ctx == nil {
-		var cancel context.CancelFunc
-		ctx, cancel = context.WithTimeout(context.Background(), _defaultTimeout)
-		defer cancel()
+	parent := a.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	pageURL, err := a.requestURL()
+	if err != nil {
+		return fmt.Errorf("doRequest: %w", err)
 	}
-	resp, err := a.doRequest(ctx)
+	// The crawl delay is waited before the fetch deadline starts.
+	if err := a.checkRobots(parent, pageURL); err != nil {
+		return fmt.Errorf("doRequest: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(parent, _defaultTimeout)
+	defer cancel()
+	resp, err := a.doRequest(ctx, pageURL)
 	if err != nil {
 		return fmt.Errorf("doRequest: %w", err)
 	}
+	defer resp.Body.Close()
+	a.pageURL = resp.Request.URL
+	a.baseURL = resp.Request.URL
+	if finalURL := resp.Request.URL.String(); cache.NormalizeURL(finalURL) != cache.NormalizeURL(a.url) {
+		a.result.FinalURL = finalURL
+	}
+	if resp.StatusCode == http.StatusNotModified && a.cached != nil {
+		a.result.StatusCode = a.cached.Details.StatusCode
+		a.page = a.revalidatedPage(resp.Header)
+		return a.parsePage()
+	}
 	if resp.StatusCode >= http.StatusBadRequest {
-		return models.NewErrorWithStatusCode(models.ErrInvalidRequest, "invalid status code", resp.StatusCode)
+		return models.NewErrorWithStatusCode(models.ErrTypeUpstreamStatus, fmt.Sprintf("invalid status code: %d", resp.StatusCode), resp.StatusCode)
 	}
+	a.result.StatusCode = resp.StatusCode
 
-	defer resp.Body.Close()
 	contentType := resp.Header.Get("Content-Type")
-	if !strings.Contains(contentType, "text/html") {
-		return fmt.Errorf("invalid content type")
+	body, err := io.ReadAll(resp.Body)
+	if neterr.IsTimeout(err) {
+		return models.NewError(models.ErrTypeTimeout, "timed out reading html")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read html: %w", err)
 	}
+	if err := checkContentType(contentType, body); err != nil {
+		return err
+	}
+	a.page = &page{body: body, contentType: contentType, header: resp.Header}
+	return a.parsePage()
+}
 
-	doc, err := html.Parse(resp.Body)
+func (a *Analyzer) parsePage() error {
+	doc, err := a.parseHTML(bytes.NewReader(a.page.body), a.page.contentType)
 	if err != nil {
-		return fmt.Errorf("failed to parse html: %w", err)
+		return err
 	}
 	a.node = doc
 	return nil
 }
 
-func (a *Analyzer) doRequest(ctx context.Context) (*http.Response, error) {
+// WithRobots makes the analyzer respect the robots.txt of the analyzed page
+// and its crawl delay, for both the page fetch and the link verification.
+func (a *Analyzer) WithRobots(cache *robots.Cache, skipDisallowedLinks bool) {
+	a.robots = cache
+	a.skipDisallowedLinks = skipDisallowedLinks
+}
+
+func (a *Analyzer) checkRobots(ctx context.Context, pageURL string) error {
+	if a.robots == nil {
+		return nil
+	}
+	userAgent := a.requestHeader().Get("User-Agent")
+	allowed, err := a.robots.Allowed(ctx, userAgent, pageURL)
+	if err != nil {
+		return models.NewError(models.ErrTypeInvalidURL, "invalid url")
+	}
+	if !allowed {
+		return models.NewError(models.ErrTypeRobotsDisallowed, "disallowed by robots.txt")
+	}
+	return a.robots.Wait(ctx, userAgent, pageURL)
+}
+
+func (a *Analyzer) requestURL() (string, error) {
 	url, err := url.ParseRequestURI(a.url)
 	if err != nil {
-		return nil, models.NewError(models.ErrTypeInvalidURL, "invalid url")
+		return "", models.NewError(models.ErrTypeInvalidURL, "invalid url")
 	}
 	if url.Scheme == "" || url.Host == "" {
-		return nil, models.NewError(models.ErrTypeInvalidURL, "invalid url")
+		return "", models.NewError(models.ErrTypeInvalidURL, "invalid url")
 	}
+	return url.String(), nil
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url.String(), nil)
+func (a *Analyzer) doRequest(ctx context.Context, pageURL string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, pageURL, nil)
 	if err != nil {
 		return nil, models.NewError(models.ErrTypeInvalidURL, "invalid request")
 	}
-	a.req = req
-	resp, err := http.DefaultClient.Do(req)
+	req.Header = a.requestHeader()
+	a.conditionalHeaders(req.Header)
+	resp, err := a.httpClient().Do(req)
+	if errors.Is(err, netguard.ErrBlockedAddress) {
+		return nil, models.NewError(models.ErrTypeBlockedAddress, "address not allowed")
+	}
+	if neterr.IsTimeout(err) {
+		return nil, models.NewError(models.ErrTypeTimeout, "timed out getting html file")
+	}
 	if err != nil {
 		return nil, models.NewError(models.ErrTypeInvalidURL, "failed to get html file")
 	}
@@ -139,37 +274,20 @@ func (a *Analyzer) HTMLVersion(n *html.Node) bool {
 	if n.Type != html.DoctypeNode {
 		return false
 	}
-	if strings.ToLower(n.Data) != "html" {
-		return false
-	}
-	if len(n.Attr) == 0 {
-		a.result.Version = &models.HTMLVersion{
-			Number: models.HTMLVersion5,
-		}
-		return true
-	}
-	var htmlVersion *models.HTMLVersion
-	if strings.Contains(n.Attr[0].Val, "4.01") {
-		htmlVersion = &models.HTMLVersion{
-			Number: models.HTMLVersion401,
-		}
-	}
-	if (strings.Contains(n.Attr[0].Val, "strict")) || (len(n.Attr) > 1 && strings.Contains(n.Attr[1].Val, "strict")) {
-		htmlVersion.Strict = true
-	}
-	a.result.Version = htmlVersion
+	a.result.Version = parseDoctype(n)
 	return true
 }
 
 func (a *Analyzer) Title(n *html.Node) bool {
 	if n.Type == html.ElementNode && n.Data == "title" {
-		a.result.Title = n.FirstChild.Data
+		a.result.Title = strings.TrimSpace(textContent(n))
 		return true
 	}
 	return false
 }
 
 func (a *Analyzer) Headings(n *html.Node) bool {
+	a.headingsSearched = true
 	if n.Type == html.ElementNode {
 		var heading models.Heading
 		switch models.Heading(n.Data) {
@@ -192,6 +310,12 @@ func (a *Analyzer) Headings(n *html.Node) bool {
 			a.result.HeadingsCounter = make(map[models.Heading]int)
 		}
 		a.result.HeadingsCounter[heading]++
+		a.outline = append(a.outline, &models.HeadingNode{
+			Level:    int(heading[1] - '0'),
+			Text:     strings.Join(strings.Fields(textContent(n)), " "),
+			ID:       attribute(n, "id"),
+			Position: len(a.outline),
+		})
 		return true
 	}
 	return false
@@ -199,24 +323,7 @@ func (a *Analyzer) Headings(n *html.Node) bool {
 
 func (a *Analyzer) HasLoginForm(n *html.Node) bool {
 	if n.Type == html.ElementNode && n.Data == "form" {
-		formAnalyzer := NewAnalyzer()
-		var hasPassword bool
-		hasPasswordFunc := func(n *html.Node) bool {
-			if n.Type == html.ElementNode && n.Data == "input" {
-				for _, attr := range n.Attr {
-					if attr.Key == "type" && attr.Val == "password" {
-						hasPassword = true
-						return true
-					}
-				}
-			}
-			return false
-		}
-
-		formAnalyzer.WithSearchSingleElements(hasPasswordFunc)
-		formAnalyzer.run(n)
-
-		if hasPassword {
+		if classifyForm(extractFormFeatures(n)).Type == models.FormTypeLogin {
 			a.result.HasLoginForm = true
 			return true
 		}
@@ -224,22 +331,27 @@ func (a *Analyzer) HasLoginForm(n *html.Node) bool {
 	return false
 }
 
+func (a *Analyzer) FormClassifications(n *html.Node) bool {
+	if n.Type == html.ElementNode && n.Data == "form" {
+		a.result.FormClassifications = append(a.result.FormClassifications, classifyForm(extractFormFeatures(n)))
+		return true
+	}
+	return false
+}
+
 func (a *Analyzer) Links(n *html.Node) bool {
 	if n.Type == html.ElementNode && n.Data == "a" {
 		for _, attr := range n.Attr {
 			if attr.Key == "href" {
-				if strings.HasPrefix(attr.Val, "http") {
-					a.result.Links = a.result.Links.AddExternalLink(attr.Val)
-					return true
-				}
-				url := a.req.URL.String() + "/" + attr.Val
-				if strings.HasPrefix(attr.Val, "#") {
-					url = a.req.URL.String() + attr.Val
+				link, ok := a.resolveLink(attr.Val)
+				if !ok {
+					return false
 				}
-				if strings.HasPrefix(attr.Val, "/") {
-					url = a.req.URL.Scheme + "://" + a.req.Host + attr.Val
+				if a.isInternalLink(link) {
+					a.result.Links = a.result.Links.AddInternalLink(link.String())
+					return true
 				}
-				a.result.Links = a.result.Links.AddInternalLink(url)
+				a.result.Links = a.result.Links.AddExternalLink(link.String())
 				return true
 			}
 		}
@@ -248,24 +360,50 @@ func (a *Analyzer) Links(n *html.Node) bool {
 }
 
 func (a *Analyzer) verifyLinks() {
-	var wg sync.WaitGroup
+	ctx := a.ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	verifier := a.linkVerifier
+	if verifier == nil {
+		verifier = a.newLinkVerifier()
+	}
+
+	links := make([]*models.Link, 0, len(a.result.Links))
 	for _, link := range a.result.Links {
-		if link == nil {
-			continue
+		if link != nil {
+			links = append(links, link)
 		}
-		wg.Add(1)
-		go func(l *models.Link) {
-			defer wg.Done()
-			if a.verifyLinkFunc != nil {
-				l.Accessible = a.verifyLinkFunc(l)
-				return
-			}
-			l.VerifyLink()
-		}(link)
 	}
-	wg.Wait()
+	var (
+		mu       sync.Mutex
+		verified int
+	)
+	a.reportProgress(0, len(links))
+	verifier.VerifyLinks(ctx, links, func() {
+		mu.Lock()
+		defer mu.Unlock()
+		verified++
+		a.reportProgress(verified, len(links))
+	})
+}
+
+func (a *Analyzer) reportProgress(verified, total int) {
+	if a.progressFunc != nil {
+		a.progressFunc(verified, total)
+	}
+}
+
+func (a *Analyzer) WithProgressFunc(progressFunc func(verified, total int)) {
+	a.progressFunc = progressFunc
+}
+
+func (a *Analyzer) WithLinkVerifier(verifier LinkVerifier) {
+	a.linkVerifier = verifier
 }
 
+// WithLinkVerifierFunc verifies every link concurrently with verifyFunc, without
+// any of the limits of HTTPLinkVerifier.
 func (a *Analyzer) WithLinkVerifierFunc(verifyFunc func(l *models.Link) bool) {
-	a.verifyLinkFunc = verifyFunc
+	a.linkVerifier = linkVerifierFunc(verifyFunc)
 }
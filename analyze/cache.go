@@ -0,0 +1,115 @@
+package analyze
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"github.com/danielperaltamadriz/html-analyzer/cache"
+)
+
+// page is the response the analyzed document was read from.
+type page struct {
+	body        []byte
+	contentType string
+	header      http.Header
+}
+
+// WithCache serves the analyses stored in results while they are fresh, and
+// revalidates them with a conditional request once they are stale. links
+// shares the verification of links between analyses. With bypass, the page is
+// fetched and its links are verified again, and both caches are refreshed.
+func (a *Analyzer) WithCache(results *cache.Results, links *cache.Links, bypass bool) {
+	a.results = results
+	a.links = links
+	a.cacheBypass = bypass
+}
+
+func (a *Analyzer) cachedResult() (*models.HTMLDetails, bool) {
+	if a.results == nil {
+		return nil, false
+	}
+	a.cacheKey = a.resultKey()
+	result, ok := a.results.Get(a.cacheKey)
+	if !ok || a.cacheBypass {
+		return nil, false
+	}
+	now := time.Now()
+	if !result.Fresh(now) {
+		a.cached = result
+		return nil, false
+	}
+	details := *result.Details
+	details.Cached = true
+	details.Age = now.Sub(result.StoredAt)
+	return &details, true
+}
+
+// resultKey identifies the analysis of the page with the options that change
+// its result.
+func (a *Analyzer) resultKey() string {
+	header := a.requestHeader()
+	keys := make([]string, 0, len(header))
+	for key := range header {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	options := []string{
+		"robots=" + strconv.FormatBool(a.robots != nil),
+		"skip_disallowed_links=" + strconv.FormatBool(a.skipDisallowedLinks),
+	}
+	for _, key := range keys {
+		options = append(options, key+": "+strings.Join(header[key], ", "))
+	}
+	return cache.Key(a.url, options...)
+}
+
+func (a *Analyzer) conditionalHeaders(header http.Header) {
+	if a.cached == nil {
+		return
+	}
+	if a.cached.ETag != "" {
+		header.Set("If-None-Match", a.cached.ETag)
+	}
+	if a.cached.LastModified != "" {
+		header.Set("If-Modified-Since", a.cached.LastModified)
+	}
+}
+
+// revalidatedPage returns the cached page with the headers of the 304
+// response, which may carry new validators and freshness.
+func (a *Analyzer) revalidatedPage(header http.Header) *page {
+	header = header.Clone()
+	if header.Get("ETag") == "" && a.cached.ETag != "" {
+		header.Set("ETag", a.cached.ETag)
+	}
+	if header.Get("Last-Modified") == "" && a.cached.LastModified != "" {
+		header.Set("Last-Modified", a.cached.LastModified)
+	}
+	return &page{body: a.cached.Body, contentType: a.cached.ContentType, header: header}
+}
+
+func (a *Analyzer) storeResult(details *models.HTMLDetails) {
+	if a.results == nil || a.page == nil {
+		return
+	}
+	now := time.Now()
+	expiresAt, ok := cache.Expiry(a.page.header, now, a.results.TTL())
+	if !ok {
+		a.results.Delete(a.cacheKey)
+		return
+	}
+	stored := *details
+	a.results.Set(a.cacheKey, &cache.Result{
+		Details:      &stored,
+		Body:         a.page.body,
+		ContentType:  a.page.contentType,
+		ETag:         a.page.header.Get("ETag"),
+		LastModified: a.page.header.Get("Last-Modified"),
+		StoredAt:     now,
+		ExpiresAt:    expiresAt,
+	})
+}
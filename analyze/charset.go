@@ -0,0 +1,138 @@
+package analyze
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding"
+)
+
+// _metaPrescanBytes is the number of bytes searched for a <meta charset>, as
+// browsers do.
+const _metaPrescanBytes = 1024
+
+var _byteOrderMarks = []struct {
+	mark []byte
+	name string
+}{
+	{mark: []byte{0xef, 0xbb, 0xbf}, name: "utf-8"},
+	{mark: []byte{0xfe, 0xff}, name: "utf-16be"},
+	{mark: []byte{0xff, 0xfe}, name: "utf-16le"},
+}
+
+// parseHTML transcodes the document to UTF-8 before parsing it. The encoding is
+// taken from the BOM, the Content-Type header or the <meta charset>, in that
+// order, and sniffed from the content when none of them is present.
+func (a *Analyzer) parseHTML(r io.Reader, contentType string) (*html.Node, error) {
+	content, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read html: %w", err)
+	}
+	enc, content, details := detectEncoding(content, contentType)
+	a.result.Encoding = details
+
+	doc, err := html.Parse(enc.NewDecoder().Reader(bytes.NewReader(content)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse html: %w", err)
+	}
+	return doc, nil
+}
+
+// detectEncoding returns the encoding of content and content without its BOM.
+func detectEncoding(content []byte, contentType string) (encoding.Encoding, []byte, *models.Encoding) {
+	details := &models.Encoding{
+		HeaderCharset: headerCharset(contentType),
+		MetaCharset:   metaCharset(content),
+	}
+	headerEncoding, headerName := lookupCharset(details.HeaderCharset)
+	metaEncoding, metaName := lookupCharset(details.MetaCharset)
+	details.Mismatch = headerEncoding != nil && metaEncoding != nil && headerName != metaName
+
+	for _, bom := range _byteOrderMarks {
+		if bytes.HasPrefix(content, bom.mark) {
+			enc, name := lookupCharset(bom.name)
+			details.Name = name
+			details.Source = models.EncodingSourceBOM
+			return enc, content[len(bom.mark):], details
+		}
+	}
+	switch {
+	case headerEncoding != nil:
+		details.Name = headerName
+		details.Source = models.EncodingSourceHeader
+		return headerEncoding, content, details
+	case metaEncoding != nil:
+		details.Name = metaName
+		details.Source = models.EncodingSourceMeta
+		return metaEncoding, content, details
+	}
+	details.Source = models.EncodingSourceSniffed
+	// ASCII only documents decode the same in UTF-8 and windows-1252, the
+	// default of DetermineEncoding, so they are reported as UTF-8.
+	if utf8.Valid(content) {
+		enc, name := lookupCharset("utf-8")
+		details.Name = name
+		return enc, content, details
+	}
+	enc, name, _ := charset.DetermineEncoding(content, "")
+	details.Name = name
+	return enc, content, details
+}
+
+func lookupCharset(label string) (encoding.Encoding, string) {
+	if label == "" {
+		return nil, ""
+	}
+	return charset.Lookup(label)
+}
+
+func headerCharset(contentType string) string {
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(params["charset"])
+}
+
+// metaCharset returns the charset declared by a <meta charset> or a
+// <meta http-equiv="Content-Type"> at the start of the document.
+func metaCharset(content []byte) string {
+	if len(content) > _metaPrescanBytes {
+		content = content[:_metaPrescanBytes]
+	}
+	z := html.NewTokenizer(bytes.NewReader(content))
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return ""
+		case html.StartTagToken, html.SelfClosingTagToken:
+			token := z.Token()
+			if token.Data != "meta" {
+				continue
+			}
+			var httpEquiv, content string
+			for _, attr := range token.Attr {
+				switch strings.ToLower(attr.Key) {
+				case "charset":
+					return strings.TrimSpace(attr.Val)
+				case "http-equiv":
+					httpEquiv = strings.ToLower(attr.Val)
+				case "content":
+					content = attr.Val
+				}
+			}
+			if httpEquiv == "content-type" {
+				if name := headerCharset(content); name != "" {
+					return name
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package analyze
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+)
+
+// _sniffBytes is the number of bytes http.DetectContentType considers.
+const _sniffBytes = 512
+
+var _htmlContentTypes = map[string]bool{
+	"text/html":             true,
+	"application/xhtml+xml": true,
+}
+
+// checkContentType accepts the HTML and XHTML content types. When the response
+// has no Content-Type, the type is sniffed from the start of the body.
+func checkContentType(contentType string, body []byte) error {
+	if contentType == "" {
+		head := body[:min(len(body), _sniffBytes)]
+		if isSniffedHTML(head) {
+			return nil
+		}
+		return models.NewError(models.ErrTypeUnsupportedContent,
+			fmt.Sprintf("invalid content type: %s", http.DetectContentType(head)))
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil || !_htmlContentTypes[mediaType] {
+		return models.NewError(models.ErrTypeUnsupportedContent, fmt.Sprintf("invalid content type: %s", contentType))
+	}
+	return nil
+}
+
+// isSniffedHTML reports whether head looks like an HTML document, including
+// XHTML documents starting with an XML declaration.
+func isSniffedHTML(head []byte) bool {
+	mediaType, _, _ := mime.ParseMediaType(http.DetectContentType(head))
+	switch mediaType {
+	case "text/html":
+		return true
+	case "text/xml":
+		return bytes.Contains(bytes.ToLower(head), []byte("<html"))
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+package analyze
+
+import (
+	"strings"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"golang.org/x/net/html"
+)
+
+type doctype struct {
+	number models.HTMLVersionNumber
+	flavor models.HTMLFlavor
+	xhtml  bool
+}
+
+// _doctypes maps the lowercased public identifiers of the published HTML and
+// XHTML DTDs to their version.
+var _doctypes = map[string]doctype{
+	"-//ietf//dtd html//en":                  {number: models.HTMLVersion20},
+	"-//ietf//dtd html 2.0//en":              {number: models.HTMLVersion20},
+	"-//ietf//dtd html 2.0 strict//en":       {number: models.HTMLVersion20, flavor: models.HTMLFlavorStrict},
+	"-//w3c//dtd html 3.2//en":               {number: models.HTMLVersion32},
+	"-//w3c//dtd html 3.2 final//en":         {number: models.HTMLVersion32},
+	"-//w3c//dtd html 4.0//en":               {number: models.HTMLVersion40, flavor: models.HTMLFlavorStrict},
+	"-//w3c//dtd html 4.0 transitional//en":  {number: models.HTMLVersion40, flavor: models.HTMLFlavorTransitional},
+	"-//w3c//dtd html 4.0 frameset//en":      {number: models.HTMLVersion40, flavor: models.HTMLFlavorFrameset},
+	"-//w3c//dtd html 4.01//en":              {number: models.HTMLVersion401, flavor: models.HTMLFlavorStrict},
+	"-//w3c//dtd html 4.01 transitional//en": {number: models.HTMLVersion401, flavor: models.HTMLFlavorTransitional},
+	"-//w3c//dtd html 4.01 frameset//en":     {number: models.HTMLVersion401, flavor: models.HTMLFlavorFrameset},
+	"-//w3c//dtd xhtml 1.0 strict//en":       {number: models.XHTMLVersion10, flavor: models.HTMLFlavorStrict, xhtml: true},
+	"-//w3c//dtd xhtml 1.0 transitional//en": {number: models.XHTMLVersion10, flavor: models.HTMLFlavorTransitional, xhtml: true},
+	"-//w3c//dtd xhtml 1.0 frameset//en":     {number: models.XHTMLVersion10, flavor: models.HTMLFlavorFrameset, xhtml: true},
+	"-//w3c//dtd xhtml 1.1//en":              {number: models.XHTMLVersion11, xhtml: true},
+	"-//w3c//dtd xhtml basic 1.0//en":        {number: models.XHTMLVersion10, flavor: models.HTMLFlavorBasic, xhtml: true},
+	"-//w3c//dtd xhtml basic 1.1//en":        {number: models.XHTMLVersion11, flavor: models.HTMLFlavorBasic, xhtml: true},
+	"-//w3c//dtd xhtml+rdfa 1.0//en":         {number: models.XHTMLVersion10, xhtml: true},
+	"-//w3c//dtd xhtml+rdfa 1.1//en":         {number: models.XHTMLVersion11, xhtml: true},
+}
+
+// _systemDoctypes is used when the public identifier is missing or unknown.
+var _systemDoctypes = map[string]doctype{
+	"http://www.w3.org/tr/html4/strict.dtd":                   {number: models.HTMLVersion401, flavor: models.HTMLFlavorStrict},
+	"http://www.w3.org/tr/html4/loose.dtd":                    {number: models.HTMLVersion401, flavor: models.HTMLFlavorTransitional},
+	"http://www.w3.org/tr/html4/frameset.dtd":                 {number: models.HTMLVersion401, flavor: models.HTMLFlavorFrameset},
+	"http://www.w3.org/tr/rec-html40/strict.dtd":              {number: models.HTMLVersion40, flavor: models.HTMLFlavorStrict},
+	"http://www.w3.org/tr/rec-html40/loose.dtd":               {number: models.HTMLVersion40, flavor: models.HTMLFlavorTransitional},
+	"http://www.w3.org/tr/rec-html40/frameset.dtd":            {number: models.HTMLVersion40, flavor: models.HTMLFlavorFrameset},
+	"http://www.w3.org/tr/xhtml1/dtd/xhtml1-strict.dtd":       {number: models.XHTMLVersion10, flavor: models.HTMLFlavorStrict, xhtml: true},
+	"http://www.w3.org/tr/xhtml1/dtd/xhtml1-transitional.dtd": {number: models.XHTMLVersion10, flavor: models.HTMLFlavorTransitional, xhtml: true},
+	"http://www.w3.org/tr/xhtml1/dtd/xhtml1-frameset.dtd":     {number: models.XHTMLVersion10, flavor: models.HTMLFlavorFrameset, xhtml: true},
+	"http://www.w3.org/tr/xhtml11/dtd/xhtml11.dtd":            {number: models.XHTMLVersion11, xhtml: true},
+	"about:legacy-compat":                                     {number: models.HTMLVersion5},
+}
+
+// _quirksPublicPrefixes are the public identifier prefixes that trigger the
+// quirks mode, as listed by the HTML Living Standard.
+var _quirksPublicPrefixes = []string{
+	"+//silmaril//dtd html pro v0r11 19970101//",
+	"-//as//dtd html 3.0 aswedit + extensions//",
+	"-//advasoft ltd//dtd html 3.0 aswedit + extensions//",
+	"-//ietf//dtd html 2.0 level 1//",
+	"-//ietf//dtd html 2.0 level 2//",
+	"-//ietf//dtd html 2.0 strict level 1//",
+	"-//ietf//dtd html 2.0 strict level 2//",
+	"-//ietf//dtd html 2.0 strict//",
+	"-//ietf//dtd html 2.0//",
+	"-//ietf//dtd html 2.1e//",
+	"-//ietf//dtd html 3.0//",
+	"-//ietf//dtd html 3.2 final//",
+	"-//ietf//dtd html 3.2//",
+	"-//ietf//dtd html 3//",
+	"-//ietf//dtd html level 0//",
+	"-//ietf//dtd html level 1//",
+	"-//ietf//dtd html level 2//",
+	"-//ietf//dtd html level 3//",
+	"-//ietf//dtd html strict level 0//",
+	"-//ietf//dtd html strict level 1//",
+	"-//ietf//dtd html strict level 2//",
+	"-//ietf//dtd html strict level 3//",
+	"-//ietf//dtd html strict//",
+	"-//ietf//dtd html//",
+	"-//metrius//dtd metrius presentational//",
+	"-//microsoft//dtd internet explorer 2.0 html strict//",
+	"-//microsoft//dtd internet explorer 2.0 html//",
+	"-//microsoft//dtd internet explorer 2.0 tables//",
+	"-//microsoft//dtd internet explorer 3.0 html strict//",
+	"-//microsoft//dtd internet explorer 3.0 html//",
+	"-//microsoft//dtd internet explorer 3.0 tables//",
+	"-//netscape comm. corp.//dtd html//",
+	"-//netscape comm. corp.//dtd strict html//",
+	"-//o'reilly and associates//dtd html 2.0//",
+	"-//o'reilly and associates//dtd html extended 1.0//",
+	"-//o'reilly and associates//dtd html extended relaxed 1.0//",
+	"-//sq//dtd html 2.0 hotmetal + extensions//",
+	"-//softquad software//dtd hotmetal pro 6.0::19990601::extensions to html 4.0//",
+	"-//softquad//dtd hotmetal pro 4.0::19971010::extensions to html 4.0//",
+	"-//spyglass//dtd html 2.0 extended//",
+	"-//sun microsystems corp.//dtd hotjava html//",
+	"-//sun microsystems corp.//dtd hotjava strict html//",
+	"-//w3c//dtd html 3 1995-03-24//",
+	"-//w3c//dtd html 3.2 draft//",
+	"-//w3c//dtd html 3.2 final//",
+	"-//w3c//dtd html 3.2//",
+	"-//w3c//dtd html 3.2s draft//",
+	"-//w3c//dtd html 4.0 frameset//",
+	"-//w3c//dtd html 4.0 transitional//",
+	"-//w3c//dtd html experimental 19960712//",
+	"-//w3c//dtd html experimental 970421//",
+	"-//w3c//dtd w3 html//",
+	"-//w3o//dtd w3 html 3.0//",
+	"-//webtechs//dtd mozilla html 2.0//",
+	"-//webtechs//dtd mozilla html//",
+}
+
+var _quirksPublicIDs = []string{
+	"-//w3o//dtd w3 html strict 3.0//en//",
+	"-/w3c/dtd html 4.0 transitional/en",
+	"html",
+}
+
+func parseDoctype(n *html.Node) *models.HTMLVersion {
+	version := &models.HTMLVersion{}
+	var hasSystemID bool
+	for _, attr := range n.Attr {
+		switch attr.Key {
+		case "public":
+			version.PublicID = attr.Val
+		case "system":
+			version.SystemID = attr.Val
+			hasSystemID = true
+		}
+	}
+	publicID := strings.ToLower(version.PublicID)
+	systemID := strings.ToLower(version.SystemID)
+	name := strings.ToLower(n.Data)
+
+	dt, ok := _doctypes[publicID]
+	if !ok {
+		dt, ok = _systemDoctypes[systemID]
+	}
+	switch {
+	case ok:
+	case publicID == "" && systemID == "":
+		dt = doctype{number: models.HTMLVersion5}
+	default:
+		dt = doctype{number: models.HTMLVersionUnknown}
+	}
+	if name != "html" {
+		dt = doctype{number: models.HTMLVersionUnknown}
+	}
+	version.Number = dt.number
+	version.Flavor = dt.flavor
+	version.Strict = dt.flavor == models.HTMLFlavorStrict
+	version.XHTML = dt.xhtml
+	version.Quirks = quirksMode(name, publicID, systemID, hasSystemID)
+	return version
+}
+
+// quirksMode follows the "initial" insertion mode of the HTML Living Standard.
+func quirksMode(name, publicID, systemID string, hasSystemID bool) models.QuirksMode {
+	if name != "html" || systemID == "http://www.ibm.com/data/dtd/v11/ibmxhtml1-transitional.dtd" {
+		return models.QuirksModeQuirks
+	}
+	for _, id := range _quirksPublicIDs {
+		if publicID == id {
+			return models.QuirksModeQuirks
+		}
+	}
+	for _, prefix := range _quirksPublicPrefixes {
+		if strings.HasPrefix(publicID, prefix) {
+			return models.QuirksModeQuirks
+		}
+	}
+	legacyHTML401 := strings.HasPrefix(publicID, "-//w3c//dtd html 4.01 frameset//") ||
+		strings.HasPrefix(publicID, "-//w3c//dtd html 4.01 transitional//")
+	if legacyHTML401 && !hasSystemID {
+		return models.QuirksModeQuirks
+	}
+	if legacyHTML401 ||
+		strings.HasPrefix(publicID, "-//w3c//dtd xhtml 1.0 frameset//") ||
+		strings.HasPrefix(publicID, "-//w3c//dtd xhtml 1.0 transitional//") {
+		return models.QuirksModeLimited
+	}
+	return models.QuirksModeNone
+}
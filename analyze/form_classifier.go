@@ -0,0 +1,207 @@
+package analyze
+
+import (
+	"math"
+	"regexp"
+	"strings"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"golang.org/x/net/html"
+)
+
+const _minFormConfidence = 0.3
+
+var (
+	_loginPattern         = regexp.MustCompile(`\b(log ?in|sign ?in|log ?on|session|auth|anmelden|einloggen)\b`)
+	_signupPattern        = regexp.MustCompile(`\b(sign ?up|register|registration|create (an )?account|join|registrieren)\b`)
+	_passwordResetPattern = regexp.MustCompile(`\b(reset|forgot|recover|change password|new password|send (reset )?link)\b`)
+	_searchPattern        = regexp.MustCompile(`\b(search|find|suche|query)\b`)
+	_contactPattern       = regexp.MustCompile(`\b(contact|message|feedback|enquiry|inquiry|kontakt)\b`)
+	_newsletterPattern    = regexp.MustCompile(`\b(newsletter|subscribe|subscription|mailing list)\b`)
+	_usernamePattern      = regexp.MustCompile(`\b(user ?name|user|login|e ?mail)\b`)
+	_personalDataPattern  = regexp.MustCompile(`\b(first ?name|last ?name|full ?name|phone|birth|confirm|repeat|terms)\b`)
+	_messagePattern       = regexp.MustCompile(`\b(message|subject|comment|question)\b`)
+	_wordSeparator        = regexp.MustCompile(`[_\-\[\].]+`)
+)
+
+// formFeatures holds the signals of a form used to classify it.
+type formFeatures struct {
+	method    string
+	action    string
+	role      string
+	submit    string
+	fields    []formField
+	textareas int
+}
+
+type formField struct {
+	name         string
+	inputType    string
+	autocomplete string
+	required     bool
+	label        string
+}
+
+func (f formFeatures) count(match func(field formField) bool) int {
+	var count int
+	for _, field := range f.fields {
+		if match(field) {
+			count++
+		}
+	}
+	return count
+}
+
+func (f formFeatures) visibleFields() int {
+	return f.count(func(field formField) bool {
+		switch field.inputType {
+		case "hidden", "submit", "button", "reset", "image", "checkbox", "radio":
+			return false
+		}
+		return true
+	})
+}
+
+func (f formFeatures) anyLabel(pattern *regexp.Regexp) bool {
+	return f.count(func(field formField) bool { return pattern.MatchString(field.label) }) > 0
+}
+
+func extractFormFeatures(form *html.Node) formFeatures {
+	features := formFeatures{
+		method: strings.ToLower(attribute(form, "method")),
+		action: normalizeWords(attribute(form, "action")),
+		role:   strings.ToLower(attribute(form, "role")),
+	}
+	var submit []string
+	var visit func(n *html.Node)
+	visit = func(n *html.Node) {
+		if n.Type == html.ElementNode {
+			switch n.Data {
+			case "input":
+				field := formField{
+					name:         attribute(n, "name"),
+					inputType:    strings.ToLower(attribute(n, "type")),
+					autocomplete: strings.ToLower(attribute(n, "autocomplete")),
+					required:     hasAttribute(n, "required"),
+					label: normalizeWords(strings.Join([]string{
+						attribute(n, "name"), attribute(n, "id"), attribute(n, "placeholder"), attribute(n, "aria-label"),
+					}, " ")),
+				}
+				if field.inputType == "" {
+					field.inputType = "text"
+				}
+				if field.inputType == "submit" || field.inputType == "image" {
+					submit = append(submit, attribute(n, "value"), attribute(n, "alt"))
+				}
+				features.fields = append(features.fields, field)
+			case "textarea", "select":
+				if n.Data == "textarea" {
+					features.textareas++
+				}
+				features.fields = append(features.fields, formField{
+					name:         attribute(n, "name"),
+					inputType:    n.Data,
+					autocomplete: strings.ToLower(attribute(n, "autocomplete")),
+					required:     hasAttribute(n, "required"),
+					label:        normalizeWords(attribute(n, "name") + " " + attribute(n, "id") + " " + attribute(n, "placeholder")),
+				})
+			case "button":
+				buttonType := strings.ToLower(attribute(n, "type"))
+				if buttonType == "" || buttonType == "submit" {
+					submit = append(submit, textContent(n), attribute(n, "aria-label"))
+				}
+			}
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			visit(c)
+		}
+	}
+	visit(form)
+	features.submit = normalizeWords(strings.Join(submit, " "))
+	return features
+}
+
+// classifyForm scores every form type from the signals of the form and
+// returns the best one. The confidence is the score of that type, capped to 1.
+func classifyForm(f formFeatures) models.FormClassification {
+	passwords := f.count(func(field formField) bool { return field.inputType == "password" })
+	currentPasswords := f.count(func(field formField) bool { return field.autocomplete == "current-password" })
+	newPasswords := f.count(func(field formField) bool { return field.autocomplete == "new-password" })
+	emails := f.count(func(field formField) bool {
+		return field.inputType == "email" || field.autocomplete == "email"
+	})
+	usernames := f.count(func(field formField) bool {
+		return field.autocomplete == "username" || field.inputType == "email" || _usernamePattern.MatchString(field.label)
+	})
+	searches := f.count(func(field formField) bool {
+		return field.inputType == "search" || _searchPattern.MatchString(field.label) || field.label == "q" || field.label == "s"
+	})
+	visible := f.visibleFields()
+	signupSubmit := _signupPattern.MatchString(f.submit)
+	resetSubmit := _passwordResetPattern.MatchString(f.submit)
+
+	scores := make(map[models.FormType]float64)
+	add := func(formType models.FormType, condition bool, weight float64) {
+		if condition {
+			scores[formType] += weight
+		}
+	}
+
+	add(models.FormTypeLogin, passwords == 1, 0.4)
+	add(models.FormTypeLogin, currentPasswords > 0, 0.3)
+	add(models.FormTypeLogin, newPasswords > 0, -0.4)
+	add(models.FormTypeLogin, passwords > 0 && usernames > 0, 0.15)
+	add(models.FormTypeLogin, _loginPattern.MatchString(f.submit), 0.3)
+	add(models.FormTypeLogin, _loginPattern.MatchString(f.action), 0.15)
+	add(models.FormTypeLogin, visible > 4, -0.3)
+	// A signup or password reset submit outweighs a single password field.
+	add(models.FormTypeLogin, signupSubmit || _signupPattern.MatchString(f.action), -0.4)
+	add(models.FormTypeLogin, resetSubmit, -0.4)
+
+	add(models.FormTypeSignup, passwords > 1, 0.3)
+	add(models.FormTypeSignup, newPasswords > 0 && currentPasswords == 0, 0.3)
+	add(models.FormTypeSignup, passwords > 0 && f.anyLabel(_personalDataPattern), 0.15)
+	add(models.FormTypeSignup, passwords > 0 && visible > 3, 0.1)
+	add(models.FormTypeSignup, signupSubmit, 0.35)
+	add(models.FormTypeSignup, _signupPattern.MatchString(f.action), 0.15)
+	add(models.FormTypeSignup, resetSubmit, -0.35)
+
+	add(models.FormTypePasswordReset, resetSubmit, 0.4)
+	add(models.FormTypePasswordReset, _passwordResetPattern.MatchString(f.action) || strings.Contains(f.action, "password"), 0.25)
+	add(models.FormTypePasswordReset, passwords == 0 && emails == 1 && visible == 1, 0.15)
+	add(models.FormTypePasswordReset, currentPasswords > 0 && newPasswords > 0, 0.5)
+	add(models.FormTypePasswordReset, newPasswords > 0 && usernames == 0, 0.15)
+
+	add(models.FormTypeSearch, searches > 0, 0.45)
+	add(models.FormTypeSearch, f.role == "search", 0.3)
+	add(models.FormTypeSearch, f.method == "" || f.method == "get", 0.1)
+	add(models.FormTypeSearch, _searchPattern.MatchString(f.submit) || _searchPattern.MatchString(f.action), 0.2)
+	add(models.FormTypeSearch, passwords > 0 || visible > 2, -0.5)
+
+	add(models.FormTypeContact, f.textareas > 0, 0.4)
+	add(models.FormTypeContact, f.anyLabel(_messagePattern), 0.2)
+	add(models.FormTypeContact, emails > 0, 0.1)
+	add(models.FormTypeContact, _contactPattern.MatchString(f.submit), 0.15)
+	add(models.FormTypeContact, _contactPattern.MatchString(f.action), 0.25)
+	add(models.FormTypeContact, passwords > 0, -0.5)
+
+	add(models.FormTypeNewsletter, emails == 1 && visible == 1, 0.3)
+	add(models.FormTypeNewsletter, _newsletterPattern.MatchString(f.submit), 0.4)
+	add(models.FormTypeNewsletter, _newsletterPattern.MatchString(f.action) || f.anyLabel(_newsletterPattern), 0.25)
+	add(models.FormTypeNewsletter, passwords > 0 || f.textareas > 0, -0.5)
+
+	classification := models.FormClassification{Type: models.FormTypeUnknown}
+	for _, formType := range models.FormTypes {
+		score := scores[formType]
+		if score >= _minFormConfidence && score > classification.Confidence {
+			classification.Type = formType
+			classification.Confidence = score
+		}
+	}
+	classification.Confidence = math.Round(math.Min(classification.Confidence, 1)*100) / 100
+	return classification
+}
+
+func normalizeWords(s string) string {
+	return strings.Join(strings.Fields(_wordSeparator.ReplaceAllString(strings.ToLower(s), " ")), " ")
+}
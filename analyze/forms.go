@@ -0,0 +1,75 @@
+package analyze
+
+import (
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"golang.org/x/net/html"
+)
+
+var _csrfTokenPattern = regexp.MustCompile(`(?i)csrf|xsrf|authenticity|verification|nonce|token`)
+
+func (a *Analyzer) Forms(n *html.Node) bool {
+	if n.Type != html.ElementNode || n.Data != "form" {
+		return false
+	}
+	features := extractFormFeatures(n)
+	form := models.Form{
+		Method: strings.ToUpper(features.method),
+		Action: attribute(n, "action"),
+		Fields: make([]models.FormField, 0, len(features.fields)),
+	}
+	if form.Method != http.MethodPost && form.Method != "DIALOG" {
+		form.Method = http.MethodGet
+	}
+
+	var hasPassword, hasCSRFToken bool
+	for _, field := range features.fields {
+		switch field.inputType {
+		case "submit", "button", "reset", "image":
+			continue
+		case "password":
+			hasPassword = true
+		case "hidden":
+			if _csrfTokenPattern.MatchString(field.name) {
+				hasCSRFToken = true
+			}
+		}
+		form.Fields = append(form.Fields, models.FormField{
+			Name:         field.name,
+			Type:         field.inputType,
+			Required:     field.required,
+			Autocomplete: field.autocomplete,
+		})
+	}
+
+	action := a.formAction(form.Action)
+	if action != nil {
+		form.Action = action.String()
+	}
+	if hasPassword && action != nil {
+		form.InsecureCredentials = action.Scheme == "http"
+		form.CrossOriginCredentials = a.pageURL != nil && action.Host != "" &&
+			(!strings.EqualFold(action.Scheme, a.pageURL.Scheme) || !strings.EqualFold(action.Host, a.pageURL.Host))
+	}
+	form.MissingCSRFToken = form.Method == http.MethodPost && !hasCSRFToken
+
+	a.result.Forms = append(a.result.Forms, form)
+	return true
+}
+
+// formAction resolves the action of a form, which is the page URL itself when
+// the action is empty.
+func (a *Analyzer) formAction(action string) *url.URL {
+	if strings.TrimSpace(action) == "" {
+		return a.pageURL
+	}
+	resolved, ok := a.resolveLink(action)
+	if !ok {
+		return nil
+	}
+	return resolved
+}
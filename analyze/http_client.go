@@ -0,0 +1,137 @@
+package analyze
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+const (
+	DefaultUserAgent = "html-analyzer/1.0 (+https://github.com/danielperaltamadriz/html-analyzer)"
+)
+
+var _credentialHeaders = []string{"Authorization", "Cookie", "Proxy-Authorization"}
+
+type HTTPClientConfig struct {
+	ProxyURL string
+	CAFile   string
+	CertFile string
+	KeyFile  string
+}
+
+// NewHTTPClient builds a client for staging environments that sit behind a
+// proxy, use an internal certificate authority or require client certificates.
+func NewHTTPClient(cfg HTTPClientConfig) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if cfg.ProxyURL != "" {
+		proxyURL, err := url.Parse(cfg.ProxyURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy url: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CAFile != "" {
+		caCert, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read ca file: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("invalid ca file: %s", cfg.CAFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if cfg.CertFile != "" || cfg.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Transport: transport}, nil
+}
+
+func (a *Analyzer) WithHTTPClient(client *http.Client) {
+	a.client = client
+}
+
+func (a *Analyzer) WithHeaders(header http.Header) {
+	if a.header == nil {
+		a.header = make(http.Header)
+	}
+	for key, values := range header {
+		for _, value := range values {
+			a.header.Add(key, value)
+		}
+	}
+}
+
+func (a *Analyzer) WithUserAgent(userAgent string) {
+	a.userAgent = userAgent
+}
+
+func (a *Analyzer) WithCookieJar(jar http.CookieJar) {
+	a.jar = jar
+}
+
+func (a *Analyzer) httpClient() *http.Client {
+	client := a.client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if a.jar == nil {
+		return client
+	}
+	withJar := *client
+	withJar.Jar = a.jar
+	return &withJar
+}
+
+func (a *Analyzer) requestHeader() http.Header {
+	header := a.header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	userAgent := a.userAgent
+	if userAgent == "" {
+		userAgent = DefaultUserAgent
+	}
+	header.Set("User-Agent", userAgent)
+	return header
+}
+
+// newLinkVerifier shares the page fetch settings with the link verification,
+// but only sends the credential headers to the host of the analyzed page.
+func (a *Analyzer) newLinkVerifier() LinkVerifier {
+	header := a.requestHeader()
+	credentials := make(http.Header)
+	for _, key := range _credentialHeaders {
+		if values, ok := header[key]; ok {
+			credentials[key] = values
+			header.Del(key)
+		}
+	}
+
+	verifier := NewHTTPLinkVerifier()
+	verifier.WithHTTPClient(a.httpClient())
+	verifier.WithHeaders(header)
+	if a.pageURL != nil && len(credentials) > 0 {
+		verifier.WithHostHeaders(a.pageURL.Host, credentials)
+	}
+	if a.robots != nil {
+		verifier.WithRobots(a.robots, a.skipDisallowedLinks)
+	}
+	if a.links != nil {
+		verifier.WithCache(a.links, a.cacheBypass)
+	}
+	return verifier
+}
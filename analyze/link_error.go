@@ -0,0 +1,56 @@
+package analyze
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"github.com/danielperaltamadriz/html-analyzer/internal/neterr"
+	"github.com/danielperaltamadriz/html-analyzer/netguard"
+)
+
+func errorCategory(err error) models.LinkErrorCategory {
+	var (
+		dnsErr         *net.DNSError
+		opErr          *net.OpError
+		urlErr         *url.Error
+		certErr        *tls.CertificateVerificationError
+		recordErr      tls.RecordHeaderError
+		unknownAuthErr x509.UnknownAuthorityError
+		hostnameErr    x509.HostnameError
+		invalidCertErr x509.CertificateInvalidError
+	)
+	switch {
+	case errors.Is(err, netguard.ErrBlockedAddress):
+		return models.LinkErrorBlocked
+	case errors.As(err, &dnsErr):
+		return models.LinkErrorDNS
+	case errors.As(err, &certErr), errors.As(err, &recordErr), errors.As(err, &unknownAuthErr),
+		errors.As(err, &hostnameErr), errors.As(err, &invalidCertErr):
+		return models.LinkErrorTLS
+	case neterr.IsTimeout(err):
+		return models.LinkErrorTimeout
+	case errors.As(err, &opErr):
+		return models.LinkErrorConnection
+	case errors.As(err, &urlErr):
+		if urlErr.Op == "parse" {
+			return models.LinkErrorInvalidURL
+		}
+		return models.LinkErrorConnection
+	}
+	return models.LinkErrorUnknown
+}
+
+func statusCategory(statusCode int) models.LinkErrorCategory {
+	switch {
+	case statusCode >= http.StatusInternalServerError:
+		return models.LinkErrorServerError
+	case statusCode >= http.StatusBadRequest:
+		return models.LinkErrorClientError
+	}
+	return models.LinkErrorNone
+}
@@ -0,0 +1,325 @@
+package analyze
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"github.com/danielperaltamadriz/html-analyzer/cache"
+	"github.com/danielperaltamadriz/html-analyzer/internal/hostlimit"
+	"github.com/danielperaltamadriz/html-analyzer/robots"
+)
+
+const (
+	_defaultVerifierWorkers            = 16
+	_defaultVerifierPerHostConcurrency = 4
+	_defaultVerifierTimeout            = 3 * time.Second
+	_defaultVerifierMaxRetries         = 2
+	_defaultVerifierBackoff            = 250 * time.Millisecond
+	_maxVerifierRetryWait              = 10 * time.Second
+	_maxDiscardedBodyBytes             = 64 << 10
+)
+
+// LinkVerifier checks the accessibility of the links found in a document.
+// done must be called once for every link, after it has been verified.
+type LinkVerifier interface {
+	VerifyLinks(ctx context.Context, links []*models.Link, done func())
+}
+
+type HTTPLinkVerifier struct {
+	client             *http.Client
+	header             http.Header
+	hostHeaders        map[string]http.Header
+	robots             *robots.Cache
+	skipDisallowed     bool
+	cache              *cache.Links
+	cacheBypass        bool
+	workers            int
+	perHostConcurrency int
+	timeout            time.Duration
+	maxRetries         int
+	backoff            time.Duration
+}
+
+func NewHTTPLinkVerifier() *HTTPLinkVerifier {
+	return &HTTPLinkVerifier{
+		client:             http.DefaultClient,
+		workers:            _defaultVerifierWorkers,
+		perHostConcurrency: _defaultVerifierPerHostConcurrency,
+		timeout:            _defaultVerifierTimeout,
+		maxRetries:         _defaultVerifierMaxRetries,
+		backoff:            _defaultVerifierBackoff,
+	}
+}
+
+func (v *HTTPLinkVerifier) WithHTTPClient(client *http.Client) {
+	v.client = client
+}
+
+func (v *HTTPLinkVerifier) WithHeaders(header http.Header) {
+	v.header = header
+}
+
+func (v *HTTPLinkVerifier) WithHostHeaders(host string, header http.Header) {
+	if v.hostHeaders == nil {
+		v.hostHeaders = make(map[string]http.Header)
+	}
+	v.hostHeaders[host] = header
+}
+
+// WithRobots spaces out the requests to every host according to its
+// robots.txt crawl delay. When skipDisallowed is true, the links disallowed by
+// robots.txt are marked as skipped instead of being fetched.
+func (v *HTTPLinkVerifier) WithRobots(cache *robots.Cache, skipDisallowed bool) {
+	v.robots = cache
+	v.skipDisallowed = skipDisallowed
+}
+
+// WithCache reuses the verification of the links verified recently. With
+// bypass, the links are verified again and the cache is refreshed.
+func (v *HTTPLinkVerifier) WithCache(links *cache.Links, bypass bool) {
+	v.cache = links
+	v.cacheBypass = bypass
+}
+
+func (v *HTTPLinkVerifier) WithWorkers(workers int) {
+	v.workers = workers
+}
+
+func (v *HTTPLinkVerifier) WithPerHostConcurrency(concurrency int) {
+	v.perHostConcurrency = concurrency
+}
+
+func (v *HTTPLinkVerifier) WithTimeout(timeout time.Duration) {
+	v.timeout = timeout
+}
+
+func (v *HTTPLinkVerifier) WithRetries(maxRetries int, backoff time.Duration) {
+	v.maxRetries = maxRetries
+	v.backoff = backoff
+}
+
+func (v *HTTPLinkVerifier) VerifyLinks(ctx context.Context, links []*models.Link, done func()) {
+	workers := v.workers
+	if workers < 1 {
+		workers = 1
+	}
+	hosts := hostlimit.New(v.perHostConcurrency)
+	queue := make(chan *models.Link)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for link := range queue {
+				v.verifyWithCache(ctx, hosts, link)
+				done()
+			}
+		}()
+	}
+	for _, link := range links {
+		queue <- link
+	}
+	close(queue)
+	wg.Wait()
+}
+
+type checkResult struct {
+	statusCode    int
+	retryAfter    time.Duration
+	latency       time.Duration
+	finalURL      string
+	redirectChain []string
+}
+
+func (v *HTTPLinkVerifier) verifyWithCache(ctx context.Context, hosts *hostlimit.Limiter, link *models.Link) {
+	if !v.cacheable(link.URL) {
+		v.verifyWithLimit(ctx, hosts, link)
+		return
+	}
+	if cached, ok := v.cache.Get(link.URL); ok && !v.cacheBypass {
+		copyVerification(link, cached)
+		return
+	}
+	v.verifyWithLimit(ctx, hosts, link)
+	if ctx.Err() == nil && !link.Skipped {
+		v.cache.Set(*link)
+	}
+}
+
+// cacheable reports whether the verification of rawURL can be shared with
+// other analyses, which is not the case when credentials are sent to its host.
+func (v *HTTPLinkVerifier) cacheable(rawURL string) bool {
+	if v.cache == nil {
+		return false
+	}
+	u, err := url.Parse(rawURL)
+	return err == nil && len(v.hostHeaders[u.Host]) == 0
+}
+
+func copyVerification(link *models.Link, from models.Link) {
+	link.Accessible = from.Accessible
+	link.StatusCode = from.StatusCode
+	link.ErrorCategory = from.ErrorCategory
+	link.Latency = from.Latency
+	link.FinalURL = from.FinalURL
+	link.RedirectChain = from.RedirectChain
+}
+
+func (v *HTTPLinkVerifier) verifyWithLimit(ctx context.Context, hosts *hostlimit.Limiter, link *models.Link) {
+	release, err := hosts.Acquire(ctx, link.URL)
+	if err != nil {
+		link.Accessible = false
+		link.ErrorCategory = errorCategory(err)
+		return
+	}
+	defer release()
+	if v.robots != nil {
+		if v.skipDisallowed {
+			if allowed, err := v.robots.Allowed(ctx, v.header.Get("User-Agent"), link.URL); err == nil && !allowed {
+				link.Skipped = true
+				link.ErrorCategory = models.LinkErrorRobots
+				return
+			}
+		}
+		if err := v.robots.Wait(ctx, v.header.Get("User-Agent"), link.URL); err != nil {
+			link.Accessible = false
+			link.ErrorCategory = errorCategory(err)
+			return
+		}
+	}
+	v.verify(ctx, link)
+}
+
+func (v *HTTPLinkVerifier) verify(ctx context.Context, link *models.Link) {
+	for attempt := 0; ; attempt++ {
+		result, err := v.check(ctx, link.URL)
+		if err != nil {
+			link.Accessible = false
+			link.ErrorCategory = errorCategory(err)
+			link.Latency = result.latency
+			return
+		}
+		if !retryable(result.statusCode) || attempt >= v.maxRetries {
+			link.StatusCode = result.statusCode
+			link.Latency = result.latency
+			link.FinalURL = result.finalURL
+			link.RedirectChain = result.redirectChain
+			link.Accessible = result.statusCode < http.StatusBadRequest
+			link.ErrorCategory = statusCategory(result.statusCode)
+			return
+		}
+		wait := result.retryAfter
+		if wait <= 0 {
+			wait = v.backoff << attempt
+		}
+		if wait > _maxVerifierRetryWait {
+			wait = _maxVerifierRetryWait
+		}
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			link.Accessible = false
+			link.ErrorCategory = errorCategory(ctx.Err())
+			return
+		}
+	}
+}
+
+// check sends a HEAD request and falls back to GET for servers that reject
+// HEAD. A HEAD that fails is not retried with GET, which would wait for the
+// timeout twice.
+func (v *HTTPLinkVerifier) check(ctx context.Context, url string) (checkResult, error) {
+	result, err := v.do(ctx, http.MethodHead, url)
+	if err != nil || !headRejected(result.statusCode) {
+		return result, err
+	}
+	return v.do(ctx, http.MethodGet, url)
+}
+
+func (v *HTTPLinkVerifier) do(ctx context.Context, method, url string) (checkResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return checkResult{}, err
+	}
+	if v.header != nil {
+		req.Header = v.header.Clone()
+	}
+	for key, values := range v.hostHeaders[req.URL.Host] {
+		req.Header[key] = values
+	}
+	start := time.Now()
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return checkResult{latency: time.Since(start)}, err
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, _maxDiscardedBodyBytes))
+	resp.Body.Close()
+	return checkResult{
+		statusCode:    resp.StatusCode,
+		retryAfter:    retryAfter(resp),
+		latency:       time.Since(start),
+		finalURL:      resp.Request.URL.String(),
+		redirectChain: redirectChain(resp),
+	}, nil
+}
+
+// redirectChain returns the URLs that were redirected before reaching the
+// final response, in the order they were requested.
+func redirectChain(resp *http.Response) []string {
+	var chain []string
+	for req := resp.Request; req.Response != nil; req = req.Response.Request {
+		chain = append([]string{req.Response.Request.URL.String()}, chain...)
+	}
+	return chain
+}
+
+func headRejected(statusCode int) bool {
+	switch statusCode {
+	case http.StatusBadRequest, http.StatusForbidden, http.StatusNotFound,
+		http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return true
+	}
+	return false
+}
+
+func retryable(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable
+}
+
+func retryAfter(resp *http.Response) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return time.Until(date)
+	}
+	return 0
+}
+
+type linkVerifierFunc func(l *models.Link) bool
+
+func (f linkVerifierFunc) VerifyLinks(_ context.Context, links []*models.Link, done func()) {
+	var wg sync.WaitGroup
+	for _, link := range links {
+		wg.Add(1)
+		go func(l *models.Link) {
+			defer wg.Done()
+			l.Accessible = f(l)
+			done()
+		}(link)
+	}
+	wg.Wait()
+}
@@ -0,0 +1,184 @@
+package analyze_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze"
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"github.com/stretchr/testify/suite"
+)
+
+type linkVerifierTestSuite struct {
+	suite.Suite
+}
+
+func TestLinkVerifierSuite(t *testing.T) {
+	suite.Run(t, new(linkVerifierTestSuite))
+}
+
+func (suite *linkVerifierTestSuite) TestVerifyLinks() {
+	var unavailableCalls atomic.Int32
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.WriteHeader(http.StatusOK)
+		case "/no-head":
+			if r.Method == http.MethodHead {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case "/unavailable-once":
+			if unavailableCalls.Add(1) == 1 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		case "/redirect":
+			http.Redirect(w, r, "/ok", http.StatusFound)
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/always-unavailable":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer fakeServer.Close()
+
+	testCases := []struct {
+		name       string
+		path       string
+		accessible bool
+		statusCode int
+		category   models.LinkErrorCategory
+	}{
+		{name: "accessible link", path: "/ok", accessible: true, statusCode: http.StatusOK},
+		{name: "fall back to GET when HEAD is rejected", path: "/no-head", accessible: true, statusCode: http.StatusOK},
+		{name: "retry when the server is unavailable", path: "/unavailable-once", accessible: true, statusCode: http.StatusOK},
+		{name: "give up after the retries", path: "/always-unavailable", accessible: false, statusCode: http.StatusServiceUnavailable, category: models.LinkErrorServerError},
+		{name: "server error link", path: "/error", accessible: false, statusCode: http.StatusInternalServerError, category: models.LinkErrorServerError},
+		{name: "not found link", path: "/missing", accessible: false, statusCode: http.StatusNotFound, category: models.LinkErrorClientError},
+	}
+
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			verifier := analyze.NewHTTPLinkVerifier()
+			verifier.WithRetries(2, time.Millisecond)
+			link := &models.Link{URL: fakeServer.URL + tc.path}
+			var done int
+			verifier.VerifyLinks(context.Background(), []*models.Link{link}, func() { done++ })
+			suite.Equal(tc.accessible, link.Accessible)
+			suite.Equal(tc.statusCode, link.StatusCode)
+			suite.Equal(tc.category, link.ErrorCategory)
+			suite.Equal(fakeServer.URL+tc.path, link.FinalURL)
+			suite.Empty(link.RedirectChain)
+			suite.Equal(1, done)
+		})
+	}
+
+	suite.Run("follow redirects", func() {
+		verifier := analyze.NewHTTPLinkVerifier()
+		link := &models.Link{URL: fakeServer.URL + "/redirect"}
+		verifier.VerifyLinks(context.Background(), []*models.Link{link}, func() {})
+		suite.True(link.Accessible)
+		suite.Equal(http.StatusOK, link.StatusCode)
+		suite.Equal(fakeServer.URL+"/ok", link.FinalURL)
+		suite.Equal([]string{fakeServer.URL + "/redirect"}, link.RedirectChain)
+	})
+}
+
+func (suite *linkVerifierTestSuite) TestVerifyLinksErrorCategory() {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer fakeServer.Close()
+	closedServer := httptest.NewServer(http.NotFoundHandler())
+	closedServer.Close()
+
+	testCases := []struct {
+		name     string
+		url      string
+		category models.LinkErrorCategory
+	}{
+		{name: "timeout", url: fakeServer.URL, category: models.LinkErrorTimeout},
+		{name: "connection refused", url: closedServer.URL, category: models.LinkErrorConnection},
+		{name: "invalid url", url: "http://[::1", category: models.LinkErrorInvalidURL},
+	}
+
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			verifier := analyze.NewHTTPLinkVerifier()
+			verifier.WithTimeout(10 * time.Millisecond)
+			link := &models.Link{URL: tc.url}
+			verifier.VerifyLinks(context.Background(), []*models.Link{link}, func() {})
+			suite.False(link.Accessible)
+			suite.Equal(tc.category, link.ErrorCategory)
+		})
+	}
+}
+
+func (suite *linkVerifierTestSuite) TestNoGETAfterFailedHEAD() {
+	var methods []string
+	var mu sync.Mutex
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		methods = append(methods, r.Method)
+		mu.Unlock()
+		time.Sleep(100 * time.Millisecond)
+	}))
+	defer fakeServer.Close()
+
+	verifier := analyze.NewHTTPLinkVerifier()
+	verifier.WithTimeout(10 * time.Millisecond)
+	link := &models.Link{URL: fakeServer.URL}
+	verifier.VerifyLinks(context.Background(), []*models.Link{link}, func() {})
+	suite.False(link.Accessible)
+	suite.Equal(models.LinkErrorTimeout, link.ErrorCategory)
+
+	mu.Lock()
+	defer mu.Unlock()
+	suite.Equal([]string{http.MethodHead}, methods)
+}
+
+func (suite *linkVerifierTestSuite) TestPerHostConcurrency() {
+	var (
+		mu       sync.Mutex
+		inFlight int
+		maxSeen  int
+	)
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxSeen {
+			maxSeen = inFlight
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+	}))
+	defer fakeServer.Close()
+
+	var links []*models.Link
+	for _, path := range []string{"/a", "/b", "/c", "/d", "/e", "/f"} {
+		links = append(links, &models.Link{URL: fakeServer.URL + path})
+	}
+	verifier := analyze.NewHTTPLinkVerifier()
+	verifier.WithWorkers(6)
+	verifier.WithPerHostConcurrency(2)
+	verifier.VerifyLinks(context.Background(), links, func() {})
+
+	for _, link := range links {
+		suite.True(link.Accessible)
+	}
+	suite.LessOrEqual(maxSeen, 2)
+}
@@ -3,10 +3,15 @@ package models
 type ErrorType int
 
 const (
-	ErrTypeUnknown         ErrorType = iota
-	ErrTypeInvalidURL                // 1
-	ErrInvalidRequest                // 2
-	ErrTypeInvalidResponse           // 3
+	ErrTypeUnknown            ErrorType = iota
+	ErrTypeInvalidURL                   // 1
+	ErrInvalidRequest                   // 2
+	ErrTypeInvalidResponse              // 3
+	ErrTypeRobotsDisallowed             // 4
+	ErrTypeBlockedAddress               // 5
+	ErrTypeUnsupportedContent           // 6
+	ErrTypeTimeout                      // 7
+	ErrTypeUpstreamStatus               // 8
 )
 
 type Error struct {
@@ -3,14 +3,43 @@ package models
 import (
 	"context"
 	"net/http"
+	"strings"
 	"time"
+
+	"github.com/danielperaltamadriz/html-analyzer/netguard"
 )
 
+var _verifyLinkClient = netguard.New().Client(http.DefaultClient)
+
 type HTMLVersionNumber string
 
 const (
-	HTMLVersion5   HTMLVersionNumber = "5"
-	HTMLVersion401 HTMLVersionNumber = "4.01"
+	HTMLVersionUnknown HTMLVersionNumber = "unknown"
+	HTMLVersion5       HTMLVersionNumber = "5"
+	HTMLVersion401     HTMLVersionNumber = "4.01"
+	HTMLVersion40      HTMLVersionNumber = "4.0"
+	HTMLVersion32      HTMLVersionNumber = "3.2"
+	HTMLVersion20      HTMLVersionNumber = "2.0"
+	XHTMLVersion10     HTMLVersionNumber = "1.0"
+	XHTMLVersion11     HTMLVersionNumber = "1.1"
+)
+
+type HTMLFlavor string
+
+const (
+	HTMLFlavorNone         HTMLFlavor = ""
+	HTMLFlavorStrict       HTMLFlavor = "strict"
+	HTMLFlavorTransitional HTMLFlavor = "transitional"
+	HTMLFlavorFrameset     HTMLFlavor = "frameset"
+	HTMLFlavorBasic        HTMLFlavor = "basic"
+)
+
+type QuirksMode string
+
+const (
+	QuirksModeNone    QuirksMode = "no-quirks"
+	QuirksModeLimited QuirksMode = "limited-quirks"
+	QuirksModeQuirks  QuirksMode = "quirks"
 )
 
 type Heading string
@@ -31,56 +60,282 @@ const (
 	LinkTypeExternal LinkType = "external"
 )
 
+type LinkKind string
+
+const (
+	LinkKindAnchor     LinkKind = "anchor"
+	LinkKindImage      LinkKind = "image"
+	LinkKindScript     LinkKind = "script"
+	LinkKindStylesheet LinkKind = "stylesheet"
+	LinkKindIframe     LinkKind = "iframe"
+	LinkKindPreload    LinkKind = "preload"
+	LinkKindMedia      LinkKind = "media"
+	LinkKindForm       LinkKind = "form"
+)
+
+type LinkErrorCategory string
+
+const (
+	LinkErrorNone        LinkErrorCategory = ""
+	LinkErrorDNS         LinkErrorCategory = "dns"
+	LinkErrorTLS         LinkErrorCategory = "tls"
+	LinkErrorTimeout     LinkErrorCategory = "timeout"
+	LinkErrorConnection  LinkErrorCategory = "connection"
+	LinkErrorInvalidURL  LinkErrorCategory = "invalid_url"
+	LinkErrorClientError LinkErrorCategory = "client_error"
+	LinkErrorServerError LinkErrorCategory = "server_error"
+	LinkErrorUnknown     LinkErrorCategory = "unknown"
+	LinkErrorRobots      LinkErrorCategory = "skipped_robots"
+	LinkErrorBlocked     LinkErrorCategory = "blocked_address"
+)
+
 type Links map[string]*Link
 
+type FormType string
+
+const (
+	FormTypeUnknown       FormType = "unknown"
+	FormTypeLogin         FormType = "login"
+	FormTypeSignup        FormType = "signup"
+	FormTypePasswordReset FormType = "password-reset"
+	FormTypeSearch        FormType = "search"
+	FormTypeContact       FormType = "contact"
+	FormTypeNewsletter    FormType = "newsletter"
+)
+
+// FormTypes lists the form types in the order they win a tie.
+var FormTypes = []FormType{
+	FormTypeLogin,
+	FormTypeSignup,
+	FormTypePasswordReset,
+	FormTypeSearch,
+	FormTypeContact,
+	FormTypeNewsletter,
+}
+
+type FormClassification struct {
+	Type       FormType
+	Confidence float64
+}
+
+type FormField struct {
+	Name         string
+	Type         string
+	Required     bool
+	Autocomplete string
+}
+
+type Form struct {
+	Method string
+	// Action is the URL the form is submitted to, resolved against the page.
+	Action string
+	Fields []FormField
+	// InsecureCredentials is set when a form with a password field is
+	// submitted over plain HTTP.
+	InsecureCredentials bool
+	// CrossOriginCredentials is set when a form with a password field is
+	// submitted to a different origin than the page.
+	CrossOriginCredentials bool
+	// MissingCSRFToken is set when a POST form has no hidden field that
+	// looks like a CSRF token.
+	MissingCSRFToken bool
+}
+
+type Severity string
+
+const (
+	SeverityInfo    Severity = "info"
+	SeverityWarning Severity = "warning"
+	SeverityError   Severity = "error"
+)
+
+type Finding struct {
+	Code     string
+	Severity Severity
+	Message  string
+}
+
+type AccessibilityFinding struct {
+	Finding
+	// Path is a CSS-like selector of the offending node.
+	Path string
+	WCAG string
+}
+
+type Hreflang struct {
+	Lang string
+	URL  string
+}
+
+type SEO struct {
+	Title             string
+	TitleLength       int
+	MetaDescription   string
+	DescriptionLength int
+	Robots            string
+	Canonicals        []string
+	Hreflangs         []Hreflang
+	OpenGraph         map[string]string
+	TwitterCard       map[string]string
+	Findings          []Finding
+}
+
+type HeadingNode struct {
+	Level int
+	Text  string
+	ID    string
+	// Position is the index of the heading in document order.
+	Position int
+	Children []*HeadingNode
+}
+
+type HeadingOutline struct {
+	Roots  []*HeadingNode
+	Issues []Finding
+}
+
 type HTMLDetails struct {
 	Version         *HTMLVersion
 	Title           string
 	HeadingsCounter map[Heading]int
+	HeadingOutline  *HeadingOutline
 	Links           Links
 	HasLoginForm    bool
+	// FormClassifications holds the classification of every form, in
+	// document order.
+	FormClassifications []FormClassification
+	Forms               []Form
+	SEO                 *SEO
+	Accessibility       []AccessibilityFinding
+	Rules               map[string]any
+	// Encoding is the character encoding the document was decoded from.
+	Encoding *Encoding
+	// FinalURL is the URL the page was served from once the redirects were
+	// followed, empty when the page was not redirected.
+	FinalURL string
+	// StatusCode is the status the page was served with, zero when the page
+	// was not fetched.
+	StatusCode int
+	// Cached is set when the analysis was served from the cache, Age being
+	// the time since it was computed.
+	Cached bool
+	Age    time.Duration
+}
+
+type EncodingSource string
+
+const (
+	EncodingSourceBOM     EncodingSource = "bom"
+	EncodingSourceHeader  EncodingSource = "header"
+	EncodingSourceMeta    EncodingSource = "meta"
+	EncodingSourceSniffed EncodingSource = "sniffed"
+)
+
+type Encoding struct {
+	Name          string
+	Source        EncodingSource
+	HeaderCharset string
+	MetaCharset   string
+	// Mismatch is set when the Content-Type header and the <meta charset>
+	// declare different encodings.
+	Mismatch bool
 }
 
 type HTMLVersion struct {
-	Number HTMLVersionNumber
-	Strict bool
+	Number   HTMLVersionNumber
+	Strict   bool
+	Flavor   HTMLFlavor
+	XHTML    bool
+	Quirks   QuirksMode
+	PublicID string
+	SystemID string
+}
+
+// Name returns a human readable name like "XHTML 1.0 Transitional".
+func (v HTMLVersion) Name() string {
+	if v.Number == HTMLVersionUnknown {
+		return "Unknown"
+	}
+	name := "HTML " + string(v.Number)
+	if v.XHTML {
+		name = "XHTML " + string(v.Number)
+	}
+	if v.Flavor == HTMLFlavorBasic {
+		return "XHTML Basic " + string(v.Number)
+	}
+	if v.Flavor != HTMLFlavorNone {
+		name += " " + strings.ToUpper(string(v.Flavor[:1])) + string(v.Flavor[1:])
+	}
+	return name
 }
 
 type Link struct {
-	URL        string
-	Count      int
-	Type       LinkType
-	Accessible bool
+	URL   string
+	Count int
+	Type  LinkType
+	// Kind is the kind of the first element referencing the URL, and Kinds
+	// the number of references of every kind.
+	Kind          LinkKind
+	Kinds         map[LinkKind]int
+	Accessible    bool
+	Skipped       bool
+	StatusCode    int
+	ErrorCategory LinkErrorCategory
+	Latency       time.Duration
+	FinalURL      string
+	RedirectChain []string
+}
+
+// anchors returns the number of <a> references of the link, every reference
+// for a link added without kinds.
+func (l *Link) anchors() int {
+	if l.Kinds == nil {
+		return l.Count
+	}
+	return l.Kinds[LinkKindAnchor]
 }
 
 func (l Links) AddInternalLink(url string) Links {
-	return l.addLink(url, LinkTypeInternal)
+	return l.addLink(url, LinkTypeInternal, LinkKindAnchor)
 }
 
 func (l Links) AddExternalLink(url string) Links {
-	return l.addLink(url, LinkTypeExternal)
+	return l.addLink(url, LinkTypeExternal, LinkKindAnchor)
+}
+
+func (l Links) AddInternalResource(url string, kind LinkKind) Links {
+	return l.addLink(url, LinkTypeInternal, kind)
 }
 
-func (l Links) addLink(url string, linkType LinkType) Links {
+func (l Links) AddExternalResource(url string, kind LinkKind) Links {
+	return l.addLink(url, LinkTypeExternal, kind)
+}
+
+func (l Links) addLink(url string, linkType LinkType, kind LinkKind) Links {
 	if l == nil {
 		l = make(map[string]*Link)
 	}
 
 	if _, ok := l[url]; ok {
 		l[url].Count++
+		l[url].Kinds[kind]++
 		return l
 	}
 	link := &Link{
 		URL:   url,
 		Count: 1,
 		Type:  linkType,
+		Kind:  kind,
+		Kinds: map[LinkKind]int{kind: 1},
 	}
 	l[url] = link
 	return l
 }
 
-func (l *Link) VerifyLink() {
-	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+// Deprecated: use analyze.HTTPLinkVerifier, which retries and limits the
+// number of concurrent requests.
+func (l *Link) VerifyLink(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
 	defer cancel()
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.URL, nil)
@@ -89,11 +344,12 @@ func (l *Link) VerifyLink() {
 		return
 	}
 
-	resp, err := http.DefaultClient.Do(req)
+	resp, err := _verifyLinkClient.Do(req)
 	if err != nil {
 		l.Accessible = false
 		return
 	}
+	defer resp.Body.Close()
 	if resp.StatusCode >= 400 {
 		l.Accessible = false
 		return
@@ -121,12 +377,53 @@ func (l Links) CountExternalLinksInaccessible() int {
 	return l.countLinksByTypeAndAccessible(LinkTypeExternal, false)
 }
 
+func (l Links) CountInternalLinksSkipped() int {
+	return l.countSkippedLinks(LinkTypeInternal)
+}
+
+func (l Links) CountExternalLinksSkipped() int {
+	return l.countSkippedLinks(LinkTypeExternal)
+}
+
+func (l Links) countSkippedLinks(linkType LinkType) int {
+	var counter int
+	for _, v := range l {
+		if v.Type == linkType && v.Skipped {
+			counter += v.anchors()
+		}
+	}
+	return counter
+}
+
 func (l Links) countLinksByTypeAndAccessible(linkType LinkType, isAccessible bool) int {
 	var counter int
 	for _, v := range l {
-		if v.Type == linkType && v.Accessible == isAccessible {
-			counter += v.Count
+		if v.Type == linkType && !v.Skipped && v.Accessible == isAccessible {
+			counter += v.anchors()
+		}
+	}
+	return counter
+}
+
+func (l Links) CountInternalLinksInaccessibleByCategory() map[LinkErrorCategory]int {
+	return l.countInaccessibleLinksByCategory(LinkTypeInternal)
+}
+
+func (l Links) CountExternalLinksInaccessibleByCategory() map[LinkErrorCategory]int {
+	return l.countInaccessibleLinksByCategory(LinkTypeExternal)
+}
+
+func (l Links) countInaccessibleLinksByCategory(linkType LinkType) map[LinkErrorCategory]int {
+	counter := make(map[LinkErrorCategory]int)
+	for _, v := range l {
+		if v.Type != linkType || v.Accessible || v.Skipped {
+			continue
+		}
+		category := v.ErrorCategory
+		if category == LinkErrorNone {
+			category = LinkErrorUnknown
 		}
+		counter[category] += v.anchors()
 	}
 	return counter
 }
@@ -139,7 +436,7 @@ func (l Links) countLinksByType(linkType LinkType) int {
 	var counter int
 	for _, v := range l {
 		if v.Type == linkType {
-			counter += v.Count
+			counter += v.anchors()
 		}
 	}
 	return counter
@@ -153,12 +450,43 @@ func (l Links) GetExternalLinks() []Link {
 	return l.getLinksByType(LinkTypeExternal)
 }
 
+// getLinksByType returns the links of the <a> elements, with Count being
+// their number of <a> references. The resources are only counted by kind.
 func (l Links) getLinksByType(linkType LinkType) []Link {
 	var links []Link
 	for _, v := range l {
-		if v.Type == linkType {
-			links = append(links, *v)
+		if v.Type == linkType && v.anchors() > 0 {
+			link := *v
+			link.Count = v.anchors()
+			links = append(links, link)
 		}
 	}
 	return links
 }
+
+type LinkKindCount struct {
+	Total        int
+	Accessible   int
+	Inaccessible int
+	Skipped      int
+}
+
+func (l Links) CountByKind() map[LinkKind]LinkKindCount {
+	counter := make(map[LinkKind]LinkKindCount)
+	for _, v := range l {
+		for kind, references := range v.Kinds {
+			count := counter[kind]
+			count.Total += references
+			switch {
+			case v.Skipped:
+				count.Skipped += references
+			case v.Accessible:
+				count.Accessible += references
+			default:
+				count.Inaccessible += references
+			}
+			counter[kind] = count
+		}
+	}
+	return counter
+}
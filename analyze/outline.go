@@ -0,0 +1,60 @@
+package analyze
+
+import (
+	"fmt"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+)
+
+// collectHeadingOutline nests every heading under the closest previous
+// heading of a lower level.
+func (a *Analyzer) collectHeadingOutline() {
+	if !a.headingsSearched {
+		return
+	}
+	outline := &models.HeadingOutline{}
+	var (
+		stack    []*models.HeadingNode
+		previous int
+	)
+	for _, heading := range a.outline {
+		for len(stack) > 0 && stack[len(stack)-1].Level >= heading.Level {
+			stack = stack[:len(stack)-1]
+		}
+		if len(stack) == 0 {
+			outline.Roots = append(outline.Roots, heading)
+		} else {
+			parent := stack[len(stack)-1]
+			parent.Children = append(parent.Children, heading)
+		}
+		stack = append(stack, heading)
+
+		if heading.Text == "" {
+			outline.Issues = append(outline.Issues, models.Finding{
+				Code:     "empty_heading",
+				Severity: models.SeverityWarning,
+				Message:  fmt.Sprintf("the h%d heading at position %d has no text", heading.Level, heading.Position),
+			})
+		}
+		if finding, ok := headingLevelSkipped(previous, heading.Level); ok {
+			finding.Message += fmt.Sprintf(" at position %d", heading.Position)
+			outline.Issues = append(outline.Issues, finding)
+		}
+		previous = heading.Level
+	}
+	outline.Issues = append(outline.Issues, a.h1Findings()...)
+	a.result.HeadingOutline = outline
+}
+
+// headingLevelSkipped reports a heading more than one level below the
+// previous one, for both the outline and the accessibility findings.
+func headingLevelSkipped(previous, level int) (models.Finding, bool) {
+	if previous == 0 || level <= previous+1 {
+		return models.Finding{}, false
+	}
+	return models.Finding{
+		Code:     "heading_level_skipped",
+		Severity: models.SeverityWarning,
+		Message:  fmt.Sprintf("the heading level jumps from h%d to h%d", previous, level),
+	}, true
+}
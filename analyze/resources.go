@@ -0,0 +1,121 @@
+package analyze
+
+import (
+	"strings"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"golang.org/x/net/html"
+)
+
+// Resources collects the URLs of the resources loaded by the page, which are
+// verified together with the links.
+func (a *Analyzer) Resources(n *html.Node) bool {
+	if n.Type != html.ElementNode || n.Namespace != "" {
+		return false
+	}
+	var found bool
+	// added keeps a URL referenced twice by the same element, like an img
+	// whose src is also a srcset candidate, from being counted twice.
+	added := make(map[string]bool)
+	add := func(href string, kind models.LinkKind) {
+		if strings.TrimSpace(href) == "" {
+			return
+		}
+		link, ok := a.resolveLink(href)
+		if !ok || added[link.String()] {
+			return
+		}
+		added[link.String()] = true
+		found = true
+		if a.isInternalLink(link) {
+			a.result.Links = a.result.Links.AddInternalResource(link.String(), kind)
+			return
+		}
+		a.result.Links = a.result.Links.AddExternalResource(link.String(), kind)
+	}
+
+	switch n.Data {
+	case "img":
+		add(attribute(n, "src"), models.LinkKindImage)
+		for _, candidate := range srcsetURLs(attribute(n, "srcset")) {
+			add(candidate, models.LinkKindImage)
+		}
+	case "source":
+		kind := models.LinkKindMedia
+		if n.Parent != nil && n.Parent.Data == "picture" {
+			kind = models.LinkKindImage
+		}
+		add(attribute(n, "src"), kind)
+		for _, candidate := range srcsetURLs(attribute(n, "srcset")) {
+			add(candidate, kind)
+		}
+	case "video", "audio", "track":
+		add(attribute(n, "src"), models.LinkKindMedia)
+		add(attribute(n, "poster"), models.LinkKindImage)
+	case "script":
+		add(attribute(n, "src"), models.LinkKindScript)
+	case "iframe":
+		add(attribute(n, "src"), models.LinkKindIframe)
+	case "form":
+		add(attribute(n, "action"), models.LinkKindForm)
+	case "link":
+		if kind, ok := linkRelKind(attribute(n, "rel")); ok {
+			add(attribute(n, "href"), kind)
+		}
+	}
+	return found
+}
+
+// linkRelKind returns the kind of the resource of a <link>, whose rel may
+// hold many tokens like "stylesheet preload". A stylesheet wins over a
+// preload, which wins over an icon.
+func linkRelKind(rel string) (models.LinkKind, bool) {
+	var icon, preload bool
+	for _, token := range strings.Fields(strings.ToLower(rel)) {
+		switch token {
+		case "stylesheet":
+			return models.LinkKindStylesheet, true
+		case "preload", "modulepreload":
+			preload = true
+		case "icon", "apple-touch-icon":
+			icon = true
+		}
+	}
+	switch {
+	case preload:
+		return models.LinkKindPreload, true
+	case icon:
+		return models.LinkKindImage, true
+	}
+	return "", false
+}
+
+// srcsetURLs returns the URLs of the image candidates of a srcset attribute,
+// like "small.jpg 480w, large.jpg 1080w". URLs may contain commas, as data
+// URLs do, so only the commas after a URL or its descriptors split them.
+func srcsetURLs(srcset string) []string {
+	var urls []string
+	s := srcset
+	for {
+		s = strings.TrimLeft(s, " \t\n\r\f,")
+		if s == "" {
+			return urls
+		}
+		end := strings.IndexAny(s, " \t\n\r\f")
+		if end < 0 {
+			end = len(s)
+		}
+		candidate := s[:end]
+		s = s[end:]
+		if trimmed := strings.TrimRight(candidate, ","); trimmed != candidate {
+			urls = append(urls, trimmed)
+			continue
+		}
+		urls = append(urls, candidate)
+		if comma := strings.Index(s, ","); comma >= 0 {
+			s = s[comma+1:]
+		} else {
+			s = ""
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package analyze
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"golang.org/x/net/html"
+)
+
+// Rule is a custom check executed while the document is traversed. Visit is
+// called for every node until it returns true, and Result is stored in
+// HTMLDetails.Rules under the rule's name once the traversal is finished.
+type Rule interface {
+	Name() string
+	Visit(n *html.Node) bool
+	Result() any
+}
+
+type RuleFactory func() Rule
+
+type Registry struct {
+	mu        sync.RWMutex
+	factories map[string]RuleFactory
+}
+
+var DefaultRegistry = NewRegistry()
+
+func NewRegistry() *Registry {
+	return &Registry{
+		factories: make(map[string]RuleFactory),
+	}
+}
+
+func Register(name string, factory RuleFactory) {
+	if err := DefaultRegistry.Register(name, factory); err != nil {
+		panic(err)
+	}
+}
+
+func (r *Registry) Register(name string, factory RuleFactory) error {
+	if factory == nil {
+		return fmt.Errorf("rule %q: nil factory", name)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.factories[name]; ok {
+		return fmt.Errorf("rule %q already registered", name)
+	}
+	r.factories[name] = factory
+	return nil
+}
+
+// registeredRule reports the name the rule was registered with, which is the
+// key of its result.
+type registeredRule struct {
+	Rule
+	name string
+}
+
+func (r registeredRule) Name() string { return r.name }
+
+// Rules returns a new instance of every registered rule, named after its
+// registration.
+func (r *Registry) Rules() []Rule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	rules := make([]Rule, 0, len(names))
+	for _, name := range names {
+		rules = append(rules, registeredRule{Rule: r.factories[name](), name: name})
+	}
+	return rules
+}
+
+func RuleResult[T any](details *models.HTMLDetails, name string) (T, bool) {
+	var zero T
+	if details == nil {
+		return zero, false
+	}
+	result, ok := details.Rules[name].(T)
+	return result, ok
+}
+
+func (a *Analyzer) WithRules(rules ...Rule) {
+	a.rules = append(a.rules, rules...)
+}
+
+func (a *Analyzer) WithRegistry(registry *Registry) {
+	a.WithRules(registry.Rules()...)
+}
+
+func (a *Analyzer) visitRules(n *html.Node) {
+	for i, rule := range a.rules {
+		if a.rulesDone[i] {
+			continue
+		}
+		if rule.Visit(n) {
+			a.rulesDone[i] = true
+		}
+	}
+}
+
+func (a *Analyzer) collectRules() {
+	for _, rule := range a.rules {
+		if _, ok := rule.(builtinRule); ok {
+			continue
+		}
+		if a.result.Rules == nil {
+			a.result.Rules = make(map[string]any)
+		}
+		a.result.Rules[rule.Name()] = rule.Result()
+	}
+}
+
+// Names of the built-in rules.
+const (
+	RuleHTMLVersion         = "html_version"
+	RuleTitle               = "title"
+	RuleLoginForm           = "login_form"
+	RuleHeadings            = "headings"
+	RuleLinks               = "links"
+	RuleResources           = "resources"
+	RuleFormClassifications = "form_classifications"
+	RuleForms               = "forms"
+	RuleSEO                 = "seo"
+	RuleAccessibility       = "accessibility"
+)
+
+// builtinRule is a check of the analyzer exposed as a Rule. Its result has
+// its own field in HTMLDetails, so it is not added to HTMLDetails.Rules.
+type builtinRule struct {
+	name   string
+	visit  SearchElement
+	result func() any
+}
+
+func (r builtinRule) Name() string            { return r.name }
+func (r builtinRule) Visit(n *html.Node) bool { return r.visit(n) }
+func (r builtinRule) Result() any             { return r.result() }
+
+// BuiltinRules returns the checks of the analyzer as rules bound to it, to be
+// passed to WithRules.
+func (a *Analyzer) BuiltinRules() []Rule {
+	// The checks finding many elements return true on every match, but a
+	// rule returning true is not visited again.
+	many := func(search SearchElement) SearchElement {
+		return func(n *html.Node) bool {
+			search(n)
+			return false
+		}
+	}
+	return []Rule{
+		builtinRule{name: RuleHTMLVersion, visit: a.HTMLVersion, result: func() any { return a.result.Version }},
+		builtinRule{name: RuleTitle, visit: a.Title, result: func() any { return a.result.Title }},
+		builtinRule{name: RuleLoginForm, visit: a.HasLoginForm, result: func() any { return a.result.HasLoginForm }},
+		builtinRule{name: RuleHeadings, visit: many(a.Headings), result: func() any { return a.result.HeadingsCounter }},
+		builtinRule{name: RuleLinks, visit: many(a.Links), result: func() any { return a.result.Links }},
+		builtinRule{name: RuleResources, visit: many(a.Resources), result: func() any { return a.result.Links }},
+		builtinRule{name: RuleFormClassifications, visit: many(a.FormClassifications), result: func() any { return a.result.FormClassifications }},
+		builtinRule{name: RuleForms, visit: many(a.Forms), result: func() any { return a.result.Forms }},
+		builtinRule{name: RuleSEO, visit: many(a.SEO), result: func() any { return a.result.SEO }},
+		builtinRule{name: RuleAccessibility, visit: many(a.Accessibility), result: func() any { return a.result.Accessibility }},
+	}
+}
@@ -0,0 +1,180 @@
+package analyze
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"golang.org/x/net/html"
+)
+
+const (
+	_minTitleLength       = 30
+	_maxTitleLength       = 60
+	_minDescriptionLength = 50
+	_maxDescriptionLength = 160
+)
+
+type seoCollector struct {
+	titles       []string
+	descriptions []string
+	result       models.SEO
+}
+
+// SEO collects the tags used by search engines and social networks. The
+// findings are computed once the whole document has been visited, the h1
+// findings from the counters of Headings.
+func (a *Analyzer) SEO(n *html.Node) bool {
+	if n.Type != html.ElementNode || n.Namespace != "" {
+		return false
+	}
+	if a.seo == nil {
+		a.seo = &seoCollector{}
+	}
+	s := a.seo
+	switch n.Data {
+	case "title":
+		s.titles = append(s.titles, strings.TrimSpace(textContent(n)))
+	case "meta":
+		name := strings.ToLower(attribute(n, "name"))
+		property := strings.ToLower(attribute(n, "property"))
+		content := strings.TrimSpace(attribute(n, "content"))
+		switch {
+		case name == "description":
+			s.descriptions = append(s.descriptions, content)
+		case name == "robots":
+			s.result.Robots = content
+		case strings.HasPrefix(property, "og:"):
+			if s.result.OpenGraph == nil {
+				s.result.OpenGraph = make(map[string]string)
+			}
+			s.result.OpenGraph[property] = content
+		case strings.HasPrefix(name, "twitter:"), strings.HasPrefix(property, "twitter:"):
+			if s.result.TwitterCard == nil {
+				s.result.TwitterCard = make(map[string]string)
+			}
+			if name == "" {
+				name = property
+			}
+			s.result.TwitterCard[name] = content
+		}
+	case "link":
+		rel := strings.Fields(strings.ToLower(attribute(n, "rel")))
+		href := attribute(n, "href")
+		if link, ok := a.resolveLink(href); ok {
+			href = link.String()
+		}
+		for _, r := range rel {
+			switch r {
+			case "canonical":
+				s.result.Canonicals = append(s.result.Canonicals, href)
+			case "alternate":
+				if lang := attribute(n, "hreflang"); lang != "" {
+					s.result.Hreflangs = append(s.result.Hreflangs, models.Hreflang{Lang: lang, URL: href})
+				}
+			}
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+func (a *Analyzer) collectSEO() {
+	if a.seo == nil {
+		return
+	}
+	s := a.seo
+	result := s.result
+	if len(s.titles) > 0 {
+		result.Title = s.titles[0]
+		result.TitleLength = utf8.RuneCountInString(result.Title)
+	}
+	if len(s.descriptions) > 0 {
+		result.MetaDescription = s.descriptions[0]
+		result.DescriptionLength = utf8.RuneCountInString(result.MetaDescription)
+	}
+	result.Findings = seoFindings(s, result, a.h1Findings())
+	a.result.SEO = &result
+}
+
+func seoFindings(s *seoCollector, result models.SEO, h1 []models.Finding) []models.Finding {
+	var findings []models.Finding
+	add := func(severity models.Severity, code, message string) {
+		findings = append(findings, models.Finding{Code: code, Severity: severity, Message: message})
+	}
+
+	switch {
+	case result.Title == "":
+		add(models.SeverityError, "empty_title", "the page has no title")
+	case result.TitleLength < _minTitleLength:
+		add(models.SeverityWarning, "title_too_short", fmt.Sprintf("the title has %d characters, less than %d", result.TitleLength, _minTitleLength))
+	case result.TitleLength > _maxTitleLength:
+		add(models.SeverityWarning, "title_too_long", fmt.Sprintf("the title has %d characters, more than %d", result.TitleLength, _maxTitleLength))
+	}
+	if len(s.titles) > 1 {
+		add(models.SeverityWarning, "multiple_titles", fmt.Sprintf("the page has %d title tags", len(s.titles)))
+	}
+
+	switch {
+	case result.MetaDescription == "":
+		add(models.SeverityWarning, "missing_meta_description", "the page has no meta description")
+	case result.DescriptionLength < _minDescriptionLength:
+		add(models.SeverityInfo, "meta_description_too_short", fmt.Sprintf("the meta description has %d characters, less than %d", result.DescriptionLength, _minDescriptionLength))
+	case result.DescriptionLength > _maxDescriptionLength:
+		add(models.SeverityInfo, "meta_description_too_long", fmt.Sprintf("the meta description has %d characters, more than %d", result.DescriptionLength, _maxDescriptionLength))
+	}
+	if len(s.descriptions) > 1 {
+		add(models.SeverityWarning, "multiple_meta_descriptions", fmt.Sprintf("the page has %d meta descriptions", len(s.descriptions)))
+	}
+	findings = append(findings, h1...)
+
+	switch {
+	case len(result.Canonicals) == 0:
+		add(models.SeverityInfo, "missing_canonical", "the page has no canonical link")
+	case len(result.Canonicals) > 1:
+		add(models.SeverityError, "multiple_canonicals", fmt.Sprintf("the page has %d canonical links", len(result.Canonicals)))
+	}
+
+	if strings.Contains(strings.ToLower(result.Robots), "noindex") {
+		add(models.SeverityWarning, "noindex", "the robots meta tag prevents the page from being indexed")
+	}
+
+	if len(result.Hreflangs) > 0 {
+		var hasDefault bool
+		for _, hreflang := range result.Hreflangs {
+			if strings.EqualFold(hreflang.Lang, "x-default") {
+				hasDefault = true
+			}
+		}
+		if !hasDefault {
+			add(models.SeverityInfo, "missing_hreflang_x_default", "the hreflang alternates have no x-default")
+		}
+	}
+
+	for _, property := range []string{"og:title", "og:description", "og:image"} {
+		if result.OpenGraph[property] == "" {
+			add(models.SeverityInfo, "missing_open_graph", "the page has no "+property+" tag")
+		}
+	}
+	if result.TwitterCard["twitter:card"] == "" {
+		add(models.SeverityInfo, "missing_twitter_card", "the page has no twitter:card tag")
+	}
+	return findings
+}
+
+// h1Findings reports a missing or duplicated h1 from the counters of
+// Headings, and nothing when the headings were not searched.
+func (a *Analyzer) h1Findings() []models.Finding {
+	if !a.headingsSearched {
+		return nil
+	}
+	switch h1 := a.result.HeadingsCounter[models.H1]; {
+	case h1 == 0:
+		return []models.Finding{{Code: "missing_h1", Severity: models.SeverityError, Message: "the page has no h1 heading"}}
+	case h1 > 1:
+		return []models.Finding{{Code: "multiple_h1", Severity: models.SeverityWarning, Message: fmt.Sprintf("the page has %d h1 headings", h1)}}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package analyze
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/publicsuffix"
+)
+
+// base applies the first <base href> of the document, as browsers do, so
+// that every relative link found afterwards is resolved against it.
+func (a *Analyzer) base(n *html.Node) {
+	if a.baseApplied || n.Type != html.ElementNode || n.Data != "base" {
+		return
+	}
+	for _, attr := range n.Attr {
+		if attr.Key != "href" {
+			continue
+		}
+		a.baseApplied = true
+		ref, err := url.Parse(strings.TrimSpace(attr.Val))
+		if err != nil {
+			return
+		}
+		if a.baseURL != nil {
+			ref = a.baseURL.ResolveReference(ref)
+		}
+		a.baseURL = ref
+		return
+	}
+}
+
+func (a *Analyzer) resolveLink(href string) (*url.URL, bool) {
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return nil, false
+	}
+	if ref.Scheme != "" && ref.Scheme != "http" && ref.Scheme != "https" {
+		return nil, false
+	}
+	if a.baseURL == nil {
+		return ref, true
+	}
+	return a.baseURL.ResolveReference(ref), true
+}
+
+func (a *Analyzer) isInternalLink(link *url.URL) bool {
+	if link.Host == "" {
+		return true
+	}
+	if a.pageURL == nil {
+		return false
+	}
+	return sameSite(a.pageURL.Hostname(), link.Hostname())
+}
+
+func sameSite(host, other string) bool {
+	host = strings.ToLower(host)
+	other = strings.ToLower(other)
+	if host == other {
+		return true
+	}
+	if net.ParseIP(host) != nil || net.ParseIP(other) != nil {
+		return false
+	}
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return false
+	}
+	otherDomain, err := publicsuffix.EffectiveTLDPlusOne(other)
+	if err != nil {
+		return false
+	}
+	return domain == otherDomain
+}
@@ -0,0 +1,147 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"github.com/danielperaltamadriz/html-analyzer/internal/safe"
+)
+
+const (
+	_maxBatchURLs        = 1000
+	_maxBatchBodySize    = 4 << 20
+	_defaultBatchWorkers = 8
+	_maxBatchWorkers     = 32
+)
+
+// BatchHandler analyzes every URL of the body, a JSON array or one URL per
+// line, and streams a result per URL as NDJSON in the order they complete.
+func (a *API) BatchHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Batch request received")
+	urls, err := parseBatchURLs(http.MaxBytesReader(w, r.Body, _maxBatchBodySize))
+	if err != nil {
+		mapError(w, r, err)
+		return
+	}
+	options, err := a.parseAnalysisOptions(r)
+	if err != nil {
+		mapError(w, r, err)
+		return
+	}
+	workers := _defaultBatchWorkers
+	if value := r.FormValue("workers"); value != "" {
+		workers, err = strconv.Atoi(value)
+		if err != nil || workers < 1 || workers > _maxBatchWorkers {
+			mapError(w, r, models.NewErrorWithStatusCode(models.ErrInvalidRequest, "invalid workers", http.StatusBadRequest))
+			return
+		}
+	}
+
+	id := requestID(r)
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set(_requestIDHeader, id)
+	w.WriteHeader(http.StatusOK)
+
+	var mu sync.Mutex
+	encoder := json.NewEncoder(w)
+	flusher, _ := w.(http.Flusher)
+	write := func(result BatchResultResponse) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err := encoder.Encode(result); err != nil {
+			fmt.Printf("failed to encode batch result, url: %s, error: %s \n", result.URL, err.Error())
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	ctx := r.Context()
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for index := range queue {
+				write(a.analyzeBatchURL(r, options, index, urls[index], id))
+			}
+		}()
+	}
+dispatch:
+	for index := range urls {
+		select {
+		case queue <- index:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(queue)
+	wg.Wait()
+}
+
+func (a *API) analyzeBatchURL(r *http.Request, options analysisOptions, index int, url, requestID string) BatchResultResponse {
+	result := BatchResultResponse{Index: index, URL: url}
+	analyzer := options.newAnalyzer()
+	analyzer.WithContext(r.Context())
+	details, err := safe.Run(func() (*models.HTMLDetails, error) {
+		return analyzer.RunFromURL(url)
+	})
+	if err != nil {
+		fmt.Printf("analyzer.RunFromURL, url: %s, error: %s \n", url, err.Error())
+		statusCode, response := newErrorResponse(err, url)
+		response.RequestID = requestID
+		result.StatusCode = statusCode
+		result.Error = &response
+		return result
+	}
+	response := NewDetailsResponse(details)
+	if !options.sendsCredentials() {
+		response.ID = a.record(r.Context(), "", url, details)
+	}
+	result.StatusCode = http.StatusOK
+	result.Details = &response
+	return result
+}
+
+// parseBatchURLs reads a JSON array of URLs, or one URL per line when the body
+// does not start with '['. Blank lines are ignored.
+func parseBatchURLs(body io.Reader) ([]string, error) {
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, models.NewErrorWithStatusCode(models.ErrInvalidRequest, "invalid body", http.StatusBadRequest)
+	}
+	data = bytes.TrimSpace(data)
+	var urls []string
+	if bytes.HasPrefix(data, []byte("[")) {
+		if err := json.Unmarshal(data, &urls); err != nil {
+			return nil, models.NewErrorWithStatusCode(models.ErrInvalidRequest, "invalid body", http.StatusBadRequest)
+		}
+	} else {
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" {
+				urls = append(urls, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, models.NewErrorWithStatusCode(models.ErrInvalidRequest, "invalid body", http.StatusBadRequest)
+		}
+	}
+	if len(urls) == 0 {
+		return nil, models.NewErrorWithStatusCode(models.ErrInvalidRequest, "no urls", http.StatusBadRequest)
+	}
+	if len(urls) > _maxBatchURLs {
+		return nil, models.NewErrorWithStatusCode(models.ErrInvalidRequest, fmt.Sprintf("too many urls, the maximum is %d", _maxBatchURLs), http.StatusBadRequest)
+	}
+	return urls, nil
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"github.com/danielperaltamadriz/html-analyzer/history"
+	"github.com/danielperaltamadriz/html-analyzer/jobs"
+)
+
+const _requestIDHeader = "X-Request-ID"
+
+const (
+	ErrCodeInternal           = "internal_error"
+	ErrCodeInvalidRequest     = "invalid_request"
+	ErrCodeInvalidURL         = "invalid_url"
+	ErrCodeInvalidResponse    = "invalid_response"
+	ErrCodeRobotsDisallowed   = "robots_disallowed"
+	ErrCodeBlockedAddress     = "blocked_address"
+	ErrCodeUnsupportedContent = "unsupported_content"
+	ErrCodeTimeout            = "timeout"
+	ErrCodeUpstreamStatus     = "upstream_status"
+	ErrCodeNotFound           = "not_found"
+	ErrCodeConflict           = "conflict"
+	ErrCodeUnavailable        = "unavailable"
+)
+
+type ErrorResponse struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	// UpstreamStatus is the status code returned by the analyzed page.
+	UpstreamStatus int    `json:"upstream_status,omitempty"`
+	URL            string `json:"url,omitempty"`
+	RequestID      string `json:"request_id"`
+}
+
+func mapJobError(w http.ResponseWriter, r *http.Request, err error) {
+	response := ErrorResponse{Code: ErrCodeInternal, Message: "internal server error"}
+	statusCode := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		response.Code, response.Message = ErrCodeNotFound, err.Error()
+		statusCode = http.StatusNotFound
+	case errors.Is(err, jobs.ErrFinished):
+		response.Code, response.Message = ErrCodeConflict, err.Error()
+		statusCode = http.StatusConflict
+	case errors.Is(err, jobs.ErrQueueFull), errors.Is(err, jobs.ErrClosed):
+		response.Code, response.Message = ErrCodeUnavailable, err.Error()
+		statusCode = http.StatusServiceUnavailable
+	}
+	writeError(w, r, statusCode, response)
+}
+
+func mapError(w http.ResponseWriter, r *http.Request, err error) {
+	statusCode, response := newErrorResponse(err, r.FormValue("url"))
+	writeError(w, r, statusCode, response)
+}
+
+// newErrorResponse maps err to the status code and the body of its response.
+func newErrorResponse(err error, url string) (int, ErrorResponse) {
+	response := ErrorResponse{
+		Code:    ErrCodeInternal,
+		Message: "internal server error",
+		URL:     url,
+	}
+	statusCode := http.StatusInternalServerError
+	if errors.Is(err, history.ErrNotFound) {
+		response.Code, response.Message = ErrCodeNotFound, err.Error()
+		statusCode = http.StatusNotFound
+	}
+	var e *models.Error
+	if errors.As(err, &e) {
+		response.Message = e.Message
+		switch e.Type {
+		case models.ErrInvalidRequest:
+			response.Code = ErrCodeInvalidRequest
+			statusCode = e.ResponseStatusCode
+			if statusCode == 0 {
+				statusCode = http.StatusBadRequest
+			}
+		case models.ErrTypeInvalidURL:
+			response.Code = ErrCodeInvalidURL
+			statusCode = http.StatusBadRequest
+		case models.ErrTypeInvalidResponse:
+			// The page or the sitemap could not be read, a failure of the
+			// upstream server.
+			response.Code = ErrCodeInvalidResponse
+			statusCode = http.StatusBadGateway
+		case models.ErrTypeRobotsDisallowed:
+			response.Code = ErrCodeRobotsDisallowed
+			statusCode = http.StatusForbidden
+		case models.ErrTypeBlockedAddress:
+			response.Code = ErrCodeBlockedAddress
+			statusCode = http.StatusForbidden
+		case models.ErrTypeUnsupportedContent:
+			response.Code = ErrCodeUnsupportedContent
+			statusCode = http.StatusUnsupportedMediaType
+		case models.ErrTypeTimeout:
+			response.Code = ErrCodeTimeout
+			statusCode = http.StatusGatewayTimeout
+		case models.ErrTypeUpstreamStatus:
+			// The 4xx of the analyzed page are forwarded, its 5xx are a bad
+			// gateway for the clients of the API.
+			response.Code = ErrCodeUpstreamStatus
+			response.UpstreamStatus = e.ResponseStatusCode
+			statusCode = e.ResponseStatusCode
+			if statusCode >= http.StatusInternalServerError {
+				statusCode = http.StatusBadGateway
+			}
+		}
+	}
+	return statusCode, response
+}
+
+func writeError(w http.ResponseWriter, r *http.Request, statusCode int, response ErrorResponse) {
+	response.RequestID = requestID(r)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set(_requestIDHeader, response.RequestID)
+	w.WriteHeader(statusCode)
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		fmt.Println("failed to encode error response: ", err)
+	}
+}
+
+// requestID returns the id sent by the client, or a new one.
+func requestID(r *http.Request) string {
+	if id := r.Header.Get(_requestIDHeader); id != "" {
+		return id
+	}
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"github.com/danielperaltamadriz/html-analyzer/cache"
+	"github.com/danielperaltamadriz/html-analyzer/history"
+)
+
+const (
+	_defaultHistoryLimit = 20
+	_maxHistoryLimit     = 100
+)
+
+// record saves details in the history and returns its id, a new one when id is
+// empty. Failing to record an analysis does not fail the request. The
+// analyses sent with the caller's credentials must not be recorded.
+func (a *API) record(ctx context.Context, id, url string, details *models.HTMLDetails) string {
+	url = cache.NormalizeURL(url)
+	if id == "" {
+		var err error
+		id, err = history.NewID()
+		if err != nil {
+			fmt.Printf("history.NewID, url: %s, error: %s \n", url, err.Error())
+			return ""
+		}
+	}
+	err := a.history.Save(ctx, history.Record{
+		ID:        id,
+		URL:       url,
+		Details:   details,
+		CreatedAt: time.Now(),
+	})
+	if err != nil {
+		fmt.Printf("history.Save, url: %s, error: %s \n", url, err.Error())
+		return ""
+	}
+	return id
+}
+
+func (a *API) HistoryHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	url := cache.NormalizeURL(r.PathValue("url"))
+	limit := _defaultHistoryLimit
+	if value := r.FormValue("limit"); value != "" {
+		var err error
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > _maxHistoryLimit {
+			mapError(w, r, models.NewErrorWithStatusCode(models.ErrInvalidRequest, "invalid limit", http.StatusBadRequest))
+			return
+		}
+	}
+	records, err := a.history.History(r.Context(), url, limit)
+	if err != nil {
+		fmt.Printf("history.History, url: %s, error: %s \n", url, err.Error())
+		mapError(w, r, err)
+		return
+	}
+	writeJSON(w, NewHistoryResponse(url, records))
+}
+
+func (a *API) DiffHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	from, err := a.history.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		mapError(w, r, err)
+		return
+	}
+	to, err := a.history.Get(r.Context(), r.PathValue("otherId"))
+	if err != nil {
+		mapError(w, r, err)
+		return
+	}
+	if cache.NormalizeURL(from.URL) != cache.NormalizeURL(to.URL) {
+		mapError(w, r, models.NewErrorWithStatusCode(models.ErrInvalidRequest, "the analyses are of different urls", http.StatusBadRequest))
+		return
+	}
+	writeJSON(w, NewDiffResponse(history.Compare(from, to)))
+}
+
+func writeJSON(w http.ResponseWriter, response any) {
+	err := json.NewEncoder(w).Encode(response)
+	if err != nil {
+		fmt.Println("failed to encode response: ", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
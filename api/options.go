@@ -0,0 +1,102 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze"
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"github.com/danielperaltamadriz/html-analyzer/cache"
+	"github.com/danielperaltamadriz/html-analyzer/robots"
+)
+
+// _allowedHeaders is the subset of request headers callers may forward to the
+// analyzed page. Headers that change the routing of the request, like Host,
+// are never forwarded.
+var _allowedHeaders = map[string]bool{
+	"Accept":          true,
+	"Accept-Language": true,
+	"Authorization":   true,
+	"Cookie":          true,
+}
+
+// _credentialHeaders are the forwarded headers that authenticate the caller.
+// The analyses sent with them are not recorded in the history.
+var _credentialHeaders = []string{"Authorization", "Cookie"}
+
+type analysisOptions struct {
+	userAgent           string
+	header              http.Header
+	client              *http.Client
+	robots              *robots.Cache
+	skipDisallowedLinks bool
+	results             *cache.Results
+	links               *cache.Links
+	cacheBypass         bool
+}
+
+func (a *API) parseAnalysisOptions(r *http.Request) (analysisOptions, error) {
+	options := analysisOptions{
+		userAgent: r.FormValue("user_agent"),
+		client:    a.client,
+		robots:    a.robots,
+		results:   a.results,
+		links:     a.links,
+	}
+	switch r.FormValue("cache") {
+	case "":
+	case "bypass":
+		options.cacheBypass = true
+	default:
+		return options, models.NewErrorWithStatusCode(models.ErrInvalidRequest, "invalid cache", http.StatusBadRequest)
+	}
+	if value := r.FormValue("skip_disallowed_links"); value != "" {
+		skip, err := strconv.ParseBool(value)
+		if err != nil {
+			return options, models.NewErrorWithStatusCode(models.ErrInvalidRequest, "invalid skip_disallowed_links", http.StatusBadRequest)
+		}
+		options.skipDisallowedLinks = skip
+	}
+	for _, value := range r.Form["header"] {
+		key, val, ok := strings.Cut(value, ":")
+		key = http.CanonicalHeaderKey(strings.TrimSpace(key))
+		if !ok || !_allowedHeaders[key] {
+			return options, models.NewErrorWithStatusCode(models.ErrInvalidRequest, "invalid header: "+key, http.StatusBadRequest)
+		}
+		if options.header == nil {
+			options.header = make(http.Header)
+		}
+		options.header.Add(key, strings.TrimSpace(val))
+	}
+	return options, nil
+}
+
+func (o analysisOptions) sendsCredentials() bool {
+	for _, key := range _credentialHeaders {
+		if _, ok := o.header[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+func (o analysisOptions) newAnalyzer() *analyze.Analyzer {
+	analyzer := NewHTMLAnalyzer()
+	if o.client != nil {
+		analyzer.WithHTTPClient(o.client)
+	}
+	if o.userAgent != "" {
+		analyzer.WithUserAgent(o.userAgent)
+	}
+	if o.header != nil {
+		analyzer.WithHeaders(o.header)
+	}
+	if o.robots != nil {
+		analyzer.WithRobots(o.robots, o.skipDisallowedLinks)
+	}
+	if o.results != nil {
+		analyzer.WithCache(o.results, o.links, o.cacheBypass)
+	}
+	return analyzer
+}
@@ -1,70 +1,425 @@
 package api
 
 import (
-	"errors"
-	"net/http"
+	"time"
 
 	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"github.com/danielperaltamadriz/html-analyzer/crawl"
+	"github.com/danielperaltamadriz/html-analyzer/history"
+	"github.com/danielperaltamadriz/html-analyzer/jobs"
+	"github.com/danielperaltamadriz/html-analyzer/sitemap"
 )
 
 type VersionResponse struct {
-	Number   string `json:"number"`
-	IsStrict bool   `json:"is_strict"`
+	Name       string `json:"name"`
+	Number     string `json:"number"`
+	IsStrict   bool   `json:"is_strict"`
+	Flavor     string `json:"flavor,omitempty"`
+	IsXHTML    bool   `json:"is_xhtml"`
+	QuirksMode string `json:"quirks_mode"`
+	PublicID   string `json:"public_id,omitempty"`
+	SystemID   string `json:"system_id,omitempty"`
 }
 
 type HeadingResponse struct {
-	H1 int `json:"h1"`
-	H2 int `json:"h2"`
-	H3 int `json:"h3"`
-	H4 int `json:"h4"`
-	H5 int `json:"h5"`
-	H6 int `json:"h6"`
+	H1      int                   `json:"h1"`
+	H2      int                   `json:"h2"`
+	H3      int                   `json:"h3"`
+	H4      int                   `json:"h4"`
+	H5      int                   `json:"h5"`
+	H6      int                   `json:"h6"`
+	Outline []HeadingNodeResponse `json:"outline,omitempty"`
+	Issues  []FindingResponse     `json:"issues,omitempty"`
+}
+
+type HeadingNodeResponse struct {
+	Level    int                   `json:"level"`
+	Text     string                `json:"text"`
+	ID       string                `json:"id,omitempty"`
+	Position int                   `json:"position"`
+	Children []HeadingNodeResponse `json:"children,omitempty"`
 }
 
 type LinkDetailResponse struct {
-	URL          string `json:"url"`
-	Count        int    `json:"count"`
-	IsAccessible bool   `json:"is_accessible"`
+	URL           string   `json:"url"`
+	Count         int      `json:"count"`
+	Kind          string   `json:"kind,omitempty"`
+	IsAccessible  bool     `json:"is_accessible"`
+	IsSkipped     bool     `json:"is_skipped,omitempty"`
+	StatusCode    int      `json:"status_code,omitempty"`
+	ErrorCategory string   `json:"error_category,omitempty"`
+	LatencyMS     int64    `json:"latency_ms"`
+	FinalURL      string   `json:"final_url,omitempty"`
+	RedirectChain []string `json:"redirect_chain,omitempty"`
 }
 
 type LinkTypeResponse struct {
+	Total                  int            `json:"total"`
+	TotalAccessible        int            `json:"total_accessible"`
+	TotalInaccessible      int            `json:"total_inaccessible"`
+	TotalSkipped           int            `json:"total_skipped"`
+	InaccessibleByCategory map[string]int `json:"inaccessible_by_category,omitempty"`
+	LinkDetails            []LinkDetailResponse
+}
+
+type LinkKindResponse struct {
 	Total             int `json:"total"`
 	TotalAccessible   int `json:"total_accessible"`
 	TotalInaccessible int `json:"total_inaccessible"`
-	LinkDetails       []LinkDetailResponse
+	TotalSkipped      int `json:"total_skipped"`
 }
 
 type LinksResponse struct {
-	Internal LinkTypeResponse `json:"internal"`
-	External LinkTypeResponse `json:"external"`
+	Internal LinkTypeResponse            `json:"internal"`
+	External LinkTypeResponse            `json:"external"`
+	ByKind   map[string]LinkKindResponse `json:"by_kind,omitempty"`
+}
+
+type FormClassificationResponse struct {
+	Type       string  `json:"type"`
+	Confidence float64 `json:"confidence"`
+}
+
+type FormFieldResponse struct {
+	Name         string `json:"name,omitempty"`
+	Type         string `json:"type"`
+	Required     bool   `json:"required"`
+	Autocomplete string `json:"autocomplete,omitempty"`
+}
+
+type FormResponse struct {
+	Method                 string              `json:"method"`
+	Action                 string              `json:"action"`
+	Fields                 []FormFieldResponse `json:"fields"`
+	InsecureCredentials    bool                `json:"insecure_credentials"`
+	CrossOriginCredentials bool                `json:"cross_origin_credentials"`
+	MissingCSRFToken       bool                `json:"missing_csrf_token"`
+}
+
+type FindingResponse struct {
+	Code     string `json:"code"`
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+type AccessibilityFindingResponse struct {
+	FindingResponse
+	Path string `json:"path"`
+	WCAG string `json:"wcag"`
+}
+
+type HreflangResponse struct {
+	Lang string `json:"lang"`
+	URL  string `json:"url"`
+}
+
+type SEOResponse struct {
+	Title             string             `json:"title"`
+	TitleLength       int                `json:"title_length"`
+	MetaDescription   string             `json:"meta_description"`
+	DescriptionLength int                `json:"description_length"`
+	Robots            string             `json:"robots,omitempty"`
+	Canonicals        []string           `json:"canonicals,omitempty"`
+	Hreflangs         []HreflangResponse `json:"hreflangs,omitempty"`
+	OpenGraph         map[string]string  `json:"open_graph,omitempty"`
+	TwitterCard       map[string]string  `json:"twitter_card,omitempty"`
+	Findings          []FindingResponse  `json:"findings"`
+}
+
+type EncodingResponse struct {
+	Name          string `json:"name"`
+	Source        string `json:"source"`
+	HeaderCharset string `json:"header_charset,omitempty"`
+	MetaCharset   string `json:"meta_charset,omitempty"`
+	Mismatch      bool   `json:"mismatch"`
 }
 
 type DetailsResponse struct {
-	Title        string           `json:"title"`
-	Version      *VersionResponse `json:"version,omitempty"`
-	Headings     HeadingResponse  `json:"headings"`
-	Links        LinksResponse    `json:"links"`
-	HasLoginForm bool             `json:"hasLoginForm"`
-}
-
-func mapError(w http.ResponseWriter, err error) {
-	var e *models.Error
-	errUnwrap := errors.Unwrap(err)
-	if errUnwrap != nil {
-		err = errUnwrap
-	}
-	if errors.As(err, &e) {
-		switch e.Type {
-		case models.ErrInvalidRequest:
-			w.WriteHeader(e.ResponseStatusCode)
-			return
-		case models.ErrTypeInvalidURL:
-			w.WriteHeader(http.StatusBadRequest)
-			return
+	// ID identifies the analysis in the history.
+	ID                  string                         `json:"id,omitempty"`
+	Title               string                         `json:"title"`
+	Version             *VersionResponse               `json:"version,omitempty"`
+	Headings            HeadingResponse                `json:"headings"`
+	Links               LinksResponse                  `json:"links"`
+	HasLoginForm        bool                           `json:"hasLoginForm"`
+	FormClassifications []FormClassificationResponse   `json:"form_classifications,omitempty"`
+	Forms               []FormResponse                 `json:"forms,omitempty"`
+	SEO                 *SEOResponse                   `json:"seo,omitempty"`
+	Accessibility       []AccessibilityFindingResponse `json:"accessibility,omitempty"`
+	Rules               map[string]any                 `json:"rules,omitempty"`
+	Encoding            *EncodingResponse              `json:"encoding,omitempty"`
+	FinalURL            string                         `json:"final_url,omitempty"`
+	Cached              bool                           `json:"cached"`
+	AgeSeconds          int                            `json:"age_seconds"`
+}
+
+// BatchResultResponse is a line of the batch response: the details of URL, or
+// the error of its analysis.
+type BatchResultResponse struct {
+	// Index is the position of URL in the request.
+	Index      int              `json:"index"`
+	URL        string           `json:"url"`
+	StatusCode int              `json:"status"`
+	Details    *DetailsResponse `json:"details,omitempty"`
+	Error      *ErrorResponse   `json:"error,omitempty"`
+}
+
+type AnalysisResponse struct {
+	ID        string          `json:"id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Details   DetailsResponse `json:"details"`
+}
+
+type HistoryResponse struct {
+	URL      string             `json:"url"`
+	Analyses []AnalysisResponse `json:"analyses"`
+}
+
+type AnalysisSummaryResponse struct {
+	ID        string    `json:"id"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ChangeResponse[T comparable] struct {
+	From T `json:"from"`
+	To   T `json:"to"`
+}
+
+type DiffResponse struct {
+	From             AnalysisSummaryResponse `json:"from"`
+	To               AnalysisSummaryResponse `json:"to"`
+	Title            *ChangeResponse[string] `json:"title,omitempty"`
+	HTMLVersion      *ChangeResponse[string] `json:"html_version,omitempty"`
+	LoginForm        *ChangeResponse[bool]   `json:"login_form,omitempty"`
+	HeadingDeltas    map[string]int          `json:"heading_deltas"`
+	NewlyBrokenLinks []string                `json:"newly_broken_links"`
+	FixedLinks       []string                `json:"fixed_links"`
+}
+
+type CrawlPageResponse struct {
+	URL     string           `json:"url"`
+	Depth   int              `json:"depth"`
+	Details *DetailsResponse `json:"details,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+type CrawlAggregateResponse struct {
+	Pages              int             `json:"pages"`
+	FailedPages        int             `json:"failed_pages"`
+	Headings           HeadingResponse `json:"headings"`
+	TotalHeadings      int             `json:"total_headings"`
+	BrokenLinks        int             `json:"broken_links"`
+	PagesWithLoginForm int             `json:"pages_with_login_form"`
+}
+
+type CrawlResponse struct {
+	Pages     []CrawlPageResponse    `json:"pages"`
+	Aggregate CrawlAggregateResponse `json:"aggregate"`
+}
+
+type SitemapPageResponse struct {
+	URL     string           `json:"url"`
+	Sitemap string           `json:"sitemap"`
+	LastMod string           `json:"lastmod,omitempty"`
+	Details *DetailsResponse `json:"details,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+type SitemapIssueResponse struct {
+	URL        string `json:"url"`
+	Type       string `json:"type"`
+	StatusCode int    `json:"status_code,omitempty"`
+	Target     string `json:"target,omitempty"`
+	Message    string `json:"message,omitempty"`
+}
+
+type SitemapErrorResponse struct {
+	URL   string `json:"url"`
+	Error string `json:"error"`
+}
+
+type SitemapResponse struct {
+	Sitemaps      []string               `json:"sitemaps"`
+	SitemapErrors []SitemapErrorResponse `json:"sitemap_errors,omitempty"`
+	Truncated     bool                   `json:"truncated"`
+	Pages         []SitemapPageResponse  `json:"pages"`
+	Issues        []SitemapIssueResponse `json:"issues"`
+}
+
+type JobProgressResponse struct {
+	LinksVerified int `json:"links_verified"`
+	LinksTotal    int `json:"links_total"`
+}
+
+type JobResponse struct {
+	ID        string              `json:"id"`
+	URL       string              `json:"url"`
+	Status    string              `json:"status"`
+	Progress  JobProgressResponse `json:"progress"`
+	Result    *DetailsResponse    `json:"result,omitempty"`
+	Error     string              `json:"error,omitempty"`
+	CreatedAt time.Time           `json:"created_at"`
+	UpdatedAt time.Time           `json:"updated_at"`
+}
+
+func NewDetailsResponse(details *models.HTMLDetails) DetailsResponse {
+	return DetailsResponse{
+		Title:               details.Title,
+		Version:             mapVersion(details.Version),
+		Headings:            mapHeadingOutline(mapHeadings(details.HeadingsCounter), details.HeadingOutline),
+		Links:               mapLinks(details.Links),
+		HasLoginForm:        details.HasLoginForm,
+		FormClassifications: mapFormClassifications(details.FormClassifications),
+		Forms:               mapForms(details.Forms),
+		SEO:                 mapSEO(details.SEO),
+		Accessibility:       mapAccessibility(details.Accessibility),
+		Rules:               details.Rules,
+		Encoding:            mapEncoding(details.Encoding),
+		FinalURL:            details.FinalURL,
+		Cached:              details.Cached,
+		AgeSeconds:          int(details.Age.Seconds()),
+	}
+}
+
+func NewHistoryResponse(url string, records []history.Record) HistoryResponse {
+	response := HistoryResponse{
+		URL:      url,
+		Analyses: make([]AnalysisResponse, 0, len(records)),
+	}
+	for _, record := range records {
+		details := NewDetailsResponse(record.Details)
+		details.ID = record.ID
+		response.Analyses = append(response.Analyses, AnalysisResponse{
+			ID:        record.ID,
+			CreatedAt: record.CreatedAt,
+			Details:   details,
+		})
+	}
+	return response
+}
+
+func NewDiffResponse(diff history.Diff) DiffResponse {
+	response := DiffResponse{
+		From:             mapAnalysisSummary(diff.From),
+		To:               mapAnalysisSummary(diff.To),
+		Title:            mapChange(diff.Title),
+		HTMLVersion:      mapChange(diff.HTMLVersion),
+		LoginForm:        mapChange(diff.LoginForm),
+		HeadingDeltas:    make(map[string]int, len(diff.HeadingDeltas)),
+		NewlyBrokenLinks: append([]string{}, diff.NewlyBrokenLinks...),
+		FixedLinks:       append([]string{}, diff.FixedLinks...),
+	}
+	for heading, delta := range diff.HeadingDeltas {
+		response.HeadingDeltas[string(heading)] = delta
+	}
+	return response
+}
+
+func mapAnalysisSummary(record history.Record) AnalysisSummaryResponse {
+	return AnalysisSummaryResponse{
+		ID:        record.ID,
+		URL:       record.URL,
+		CreatedAt: record.CreatedAt,
+	}
+}
+
+func mapChange[T comparable](change *history.Change[T]) *ChangeResponse[T] {
+	if change == nil {
+		return nil
+	}
+	return &ChangeResponse[T]{From: change.From, To: change.To}
+}
+
+func NewCrawlResponse(result *crawl.Result) CrawlResponse {
+	response := CrawlResponse{
+		Pages: make([]CrawlPageResponse, 0, len(result.Pages)),
+		Aggregate: CrawlAggregateResponse{
+			Pages:              result.Aggregate.Pages,
+			FailedPages:        result.Aggregate.FailedPages,
+			Headings:           mapHeadings(result.Aggregate.HeadingsCounter),
+			TotalHeadings:      result.Aggregate.TotalHeadings,
+			BrokenLinks:        result.Aggregate.BrokenLinks,
+			PagesWithLoginForm: result.Aggregate.PagesWithLoginForm,
+		},
+	}
+	for _, page := range result.Pages {
+		pageResponse := CrawlPageResponse{
+			URL:   page.URL,
+			Depth: page.Depth,
+		}
+		if page.Err != nil {
+			pageResponse.Error = page.Err.Error()
+		}
+		if page.Details != nil {
+			details := NewDetailsResponse(page.Details)
+			pageResponse.Details = &details
+		}
+		response.Pages = append(response.Pages, pageResponse)
+	}
+	return response
+}
+
+func NewSitemapResponse(result *sitemap.Result) SitemapResponse {
+	response := SitemapResponse{
+		Sitemaps:  result.Sitemaps,
+		Truncated: result.Truncated,
+		Pages:     make([]SitemapPageResponse, 0, len(result.Pages)),
+		Issues:    make([]SitemapIssueResponse, 0, len(result.Issues)),
+	}
+	for _, sitemapErr := range result.SitemapErrors {
+		response.SitemapErrors = append(response.SitemapErrors, SitemapErrorResponse{
+			URL:   sitemapErr.URL,
+			Error: sitemapErr.Err.Error(),
+		})
+	}
+	for _, page := range result.Pages {
+		pageResponse := SitemapPageResponse{
+			URL:     page.Loc,
+			Sitemap: page.Sitemap,
+			LastMod: page.LastMod,
 		}
+		if page.Err != nil {
+			pageResponse.Error = page.Err.Error()
+		}
+		if page.Details != nil {
+			details := NewDetailsResponse(page.Details)
+			pageResponse.Details = &details
+		}
+		response.Pages = append(response.Pages, pageResponse)
 	}
-	w.WriteHeader(http.StatusInternalServerError)
+	for _, issue := range result.Issues {
+		response.Issues = append(response.Issues, SitemapIssueResponse{
+			URL:        issue.URL,
+			Type:       string(issue.Type),
+			StatusCode: issue.StatusCode,
+			Target:     issue.Target,
+			Message:    issue.Message,
+		})
+	}
+	return response
+}
 
+func NewJobResponse(job jobs.Job) JobResponse {
+	response := JobResponse{
+		ID:     job.ID,
+		URL:    job.URL,
+		Status: string(job.Status),
+		Progress: JobProgressResponse{
+			LinksVerified: job.Progress.LinksVerified,
+			LinksTotal:    job.Progress.LinksTotal,
+		},
+		CreatedAt: job.CreatedAt,
+		UpdatedAt: job.UpdatedAt,
+	}
+	if job.Details != nil {
+		details := NewDetailsResponse(job.Details)
+		response.Result = &details
+	}
+	if job.Err != nil {
+		response.Error = job.Err.Error()
+	}
+	return response
 }
 
 func mapVersion(version *models.HTMLVersion) *VersionResponse {
@@ -72,9 +427,121 @@ func mapVersion(version *models.HTMLVersion) *VersionResponse {
 		return nil
 	}
 	return &VersionResponse{
-		Number:   string(version.Number),
-		IsStrict: version.Strict,
+		Name:       version.Name(),
+		Number:     string(version.Number),
+		IsStrict:   version.Strict,
+		Flavor:     string(version.Flavor),
+		IsXHTML:    version.XHTML,
+		QuirksMode: string(version.Quirks),
+		PublicID:   version.PublicID,
+		SystemID:   version.SystemID,
+	}
+}
+
+func mapEncoding(encoding *models.Encoding) *EncodingResponse {
+	if encoding == nil {
+		return nil
+	}
+	return &EncodingResponse{
+		Name:          encoding.Name,
+		Source:        string(encoding.Source),
+		HeaderCharset: encoding.HeaderCharset,
+		MetaCharset:   encoding.MetaCharset,
+		Mismatch:      encoding.Mismatch,
+	}
+}
+
+func mapFormClassifications(classifications []models.FormClassification) []FormClassificationResponse {
+	if len(classifications) == 0 {
+		return nil
+	}
+	response := make([]FormClassificationResponse, 0, len(classifications))
+	for _, classification := range classifications {
+		response = append(response, FormClassificationResponse{
+			Type:       string(classification.Type),
+			Confidence: classification.Confidence,
+		})
+	}
+	return response
+}
+
+func mapForms(forms []models.Form) []FormResponse {
+	if len(forms) == 0 {
+		return nil
+	}
+	response := make([]FormResponse, 0, len(forms))
+	for _, form := range forms {
+		fields := make([]FormFieldResponse, 0, len(form.Fields))
+		for _, field := range form.Fields {
+			fields = append(fields, FormFieldResponse{
+				Name:         field.Name,
+				Type:         field.Type,
+				Required:     field.Required,
+				Autocomplete: field.Autocomplete,
+			})
+		}
+		response = append(response, FormResponse{
+			Method:                 form.Method,
+			Action:                 form.Action,
+			Fields:                 fields,
+			InsecureCredentials:    form.InsecureCredentials,
+			CrossOriginCredentials: form.CrossOriginCredentials,
+			MissingCSRFToken:       form.MissingCSRFToken,
+		})
+	}
+	return response
+}
+
+func mapSEO(seo *models.SEO) *SEOResponse {
+	if seo == nil {
+		return nil
 	}
+	response := &SEOResponse{
+		Title:             seo.Title,
+		TitleLength:       seo.TitleLength,
+		MetaDescription:   seo.MetaDescription,
+		DescriptionLength: seo.DescriptionLength,
+		Robots:            seo.Robots,
+		Canonicals:        seo.Canonicals,
+		OpenGraph:         seo.OpenGraph,
+		TwitterCard:       seo.TwitterCard,
+		Findings:          mapFindings(seo.Findings),
+	}
+	for _, hreflang := range seo.Hreflangs {
+		response.Hreflangs = append(response.Hreflangs, HreflangResponse{Lang: hreflang.Lang, URL: hreflang.URL})
+	}
+	return response
+}
+
+func mapAccessibility(findings []models.AccessibilityFinding) []AccessibilityFindingResponse {
+	if findings == nil {
+		return nil
+	}
+	response := make([]AccessibilityFindingResponse, 0, len(findings))
+	for _, finding := range findings {
+		response = append(response, AccessibilityFindingResponse{
+			FindingResponse: FindingResponse{
+				Code:     finding.Code,
+				Severity: string(finding.Severity),
+				Message:  finding.Message,
+			},
+			Path: finding.Path,
+			WCAG: finding.WCAG,
+		})
+	}
+	return response
+}
+
+func mapFindings(findings []models.Finding) []FindingResponse {
+	response := make([]FindingResponse, 0, len(findings))
+	for _, finding := range findings {
+		response = append(response, FindingResponse{
+			Code:     finding.Code,
+			Severity: string(finding.Severity),
+			Message:  finding.Message,
+		})
+	}
+	return response
 }
 
 func mapHeadings(headings map[models.Heading]int) HeadingResponse {
@@ -98,31 +565,98 @@ func mapHeadings(headings map[models.Heading]int) HeadingResponse {
 	return response
 }
 
+func mapHeadingOutline(response HeadingResponse, outline *models.HeadingOutline) HeadingResponse {
+	if outline == nil {
+		return response
+	}
+	response.Outline = mapHeadingNodes(outline.Roots)
+	if len(outline.Issues) > 0 {
+		response.Issues = mapFindings(outline.Issues)
+	}
+	return response
+}
+
+func mapHeadingNodes(nodes []*models.HeadingNode) []HeadingNodeResponse {
+	if len(nodes) == 0 {
+		return nil
+	}
+	response := make([]HeadingNodeResponse, 0, len(nodes))
+	for _, node := range nodes {
+		response = append(response, HeadingNodeResponse{
+			Level:    node.Level,
+			Text:     node.Text,
+			ID:       node.ID,
+			Position: node.Position,
+			Children: mapHeadingNodes(node.Children),
+		})
+	}
+	return response
+}
+
 func mapLinks(links models.Links) LinksResponse {
 	return LinksResponse{
 		Internal: LinkTypeResponse{
-			Total:             links.CountInternalLinks(),
-			TotalAccessible:   links.CountInternalLinksAccessible(),
-			TotalInaccessible: links.CountInternalLinksInaccessible(),
-			LinkDetails:       mapLinkDetailsResponse(links.GetInternalLinks()),
+			Total:                  links.CountInternalLinks(),
+			TotalAccessible:        links.CountInternalLinksAccessible(),
+			TotalInaccessible:      links.CountInternalLinksInaccessible(),
+			TotalSkipped:           links.CountInternalLinksSkipped(),
+			InaccessibleByCategory: mapLinkErrorCategories(links.CountInternalLinksInaccessibleByCategory()),
+			LinkDetails:            mapLinkDetailsResponse(links.GetInternalLinks()),
 		},
 		External: LinkTypeResponse{
-			Total:             links.CountExternalLinks(),
-			TotalAccessible:   links.CountExternalLinksAccessible(),
-			TotalInaccessible: links.CountExternalLinksInaccessible(),
-			LinkDetails:       mapLinkDetailsResponse(links.GetExternalLinks()),
+			Total:                  links.CountExternalLinks(),
+			TotalAccessible:        links.CountExternalLinksAccessible(),
+			TotalInaccessible:      links.CountExternalLinksInaccessible(),
+			TotalSkipped:           links.CountExternalLinksSkipped(),
+			InaccessibleByCategory: mapLinkErrorCategories(links.CountExternalLinksInaccessibleByCategory()),
+			LinkDetails:            mapLinkDetailsResponse(links.GetExternalLinks()),
 		},
+		ByKind: mapLinkKinds(links.CountByKind()),
+	}
+}
+
+func mapLinkKinds(kinds map[models.LinkKind]models.LinkKindCount) map[string]LinkKindResponse {
+	if len(kinds) == 0 {
+		return nil
+	}
+	response := make(map[string]LinkKindResponse, len(kinds))
+	for kind, count := range kinds {
+		response[string(kind)] = LinkKindResponse{
+			Total:             count.Total,
+			TotalAccessible:   count.Accessible,
+			TotalInaccessible: count.Inaccessible,
+			TotalSkipped:      count.Skipped,
+		}
 	}
+	return response
 }
 
 func mapLinkDetailsResponse(links []models.Link) []LinkDetailResponse {
 	var response []LinkDetailResponse
 	for _, link := range links {
 		response = append(response, LinkDetailResponse{
-			URL:          link.URL,
-			Count:        link.Count,
-			IsAccessible: link.Accessible,
+			URL:           link.URL,
+			Count:         link.Count,
+			Kind:          string(link.Kind),
+			IsAccessible:  link.Accessible,
+			IsSkipped:     link.Skipped,
+			StatusCode:    link.StatusCode,
+			ErrorCategory: string(link.ErrorCategory),
+			LatencyMS:     link.Latency.Milliseconds(),
+			FinalURL:      link.FinalURL,
+			RedirectChain: link.RedirectChain,
 		})
 	}
 	return response
 }
+
+func mapLinkErrorCategories(categories map[models.LinkErrorCategory]int) map[string]int {
+	if len(categories) == 0 {
+		return nil
+	}
+	response := make(map[string]int, len(categories))
+	for category, count := range categories {
+		response[string(category)] = count
+	}
+	return response
+}
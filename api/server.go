@@ -5,41 +5,111 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/netip"
+	"regexp"
+	"strconv"
+	"time"
 
 	"github.com/danielperaltamadriz/html-analyzer/analyze"
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"github.com/danielperaltamadriz/html-analyzer/cache"
+	"github.com/danielperaltamadriz/html-analyzer/crawl"
+	"github.com/danielperaltamadriz/html-analyzer/history"
+	"github.com/danielperaltamadriz/html-analyzer/jobs"
+	"github.com/danielperaltamadriz/html-analyzer/netguard"
+	"github.com/danielperaltamadriz/html-analyzer/robots"
+	"github.com/danielperaltamadriz/html-analyzer/sitemap"
 )
 
 const (
 	_defaultPort = 8080
+
+	_maxCrawlPages = 500
+
+	_maxSitemapURLs = 1000
 )
 
 type API struct {
 	server *http.Server
+	jobs   *jobs.Manager
+	robots *robots.Cache
+	client *http.Client
+	// results and links are shared by all the requests, so that repeated
+	// analyses of the same pages are served from the cache.
+	results *cache.Results
+	links   *cache.Links
+	history history.Store
 }
 
 type APIConfig struct {
-	Port int
+	Port         int
+	IgnoreRobots bool
+	// AllowedNetworks are the private networks the API may fetch from, all
+	// the other non-public addresses are blocked.
+	AllowedNetworks []netip.Prefix
+	// CacheTTL and LinkCacheTTL bound how long the analyses and the link
+	// verifications are cached, the package defaults are used when zero.
+	CacheTTL     time.Duration
+	LinkCacheTTL time.Duration
+	// History records every analysis, in memory when nil.
+	History history.Store
 }
 
 func NewAPI(cfg APIConfig) *API {
 	if cfg.Port == 0 {
 		cfg.Port = _defaultPort
 	}
-	return &API{
+	api := &API{
 		server: &http.Server{
 			Addr: fmt.Sprintf(":%d", cfg.Port),
 		},
+		jobs:    jobs.NewManager(jobs.NewMemoryStore()),
+		client:  netguard.New(cfg.AllowedNetworks...).Client(http.DefaultClient),
+		results: cache.NewResults(),
+		links:   cache.NewLinks(),
+		history: cfg.History,
+	}
+	if api.history == nil {
+		api.history = history.NewMemoryStore()
+	}
+	api.jobs.WithCompletedFunc(func(job jobs.Job) {
+		api.record(context.Background(), job.ID, job.URL, job.Details)
+	})
+	if cfg.CacheTTL > 0 {
+		api.results.WithTTL(cfg.CacheTTL)
 	}
+	if cfg.LinkCacheTTL > 0 {
+		api.links.WithTTL(cfg.LinkCacheTTL)
+	}
+	if !cfg.IgnoreRobots {
+		api.robots = robots.NewCache(api.client, analyze.DefaultUserAgent)
+	}
+	api.server.Handler = api.routes()
+	return api
+}
+
+func (a *API) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/analyzes", a.HTMLHandler)
+	mux.HandleFunc("POST /v1/analyzes", a.CreateJobHandler)
+	mux.HandleFunc("POST /v1/analyzes/batch", a.BatchHandler)
+	mux.HandleFunc("GET /v1/analyzes/{id}", a.GetJobHandler)
+	mux.HandleFunc("DELETE /v1/analyzes/{id}", a.CancelJobHandler)
+	mux.HandleFunc("GET /v1/analyzes/{id}/diff/{otherId}", a.DiffHandler)
+	mux.HandleFunc("GET /v1/urls/{url}/history", a.HistoryHandler)
+	mux.HandleFunc("/v1/crawls", a.CrawlHandler)
+	mux.HandleFunc("GET /v1/sitemaps", a.SitemapHandler)
+	return mux
 }
 
 func (a *API) Shutdown() error {
 	fmt.Println("Shutting down server")
+	defer a.jobs.Close()
 	return a.server.Shutdown(context.Background())
 }
 
 func (a *API) Start() error {
 	fmt.Println("Starting server on port " + a.server.Addr)
-	http.HandleFunc("/v1/analyzes", a.HTMLHandler)
 	err := a.server.ListenAndServe()
 	if err != nil && err != http.ErrServerClosed {
 		return fmt.Errorf("server.ListenAndServe: %w", err)
@@ -50,22 +120,25 @@ func (a *API) Start() error {
 func (a *API) HTMLHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Println("Request received")
 	w.Header().Set("Content-Type", "application/json")
-	analyzer := analyze.NewAnalyzer()
-	analyzer.WithSearchSingleElements(analyzer.HTMLVersion, analyzer.Title, analyzer.HasLoginForm)
-	analyzer.WithSearchManyElements(analyzer.Headings, analyzer.Links)
+	options, err := a.parseAnalysisOptions(r)
+	if err != nil {
+		mapError(w, r, err)
+		return
+	}
+	analyzer := options.newAnalyzer()
 	url := r.FormValue("url")
 	details, err := analyzer.RunFromURL(url)
 	if err != nil {
 		fmt.Printf("analyzer.RunFromURL, url: %s, error: %s \n", url, err.Error())
-		mapError(w, err)
+		mapError(w, r, err)
 		return
 	}
-	response := DetailsResponse{
-		Title:        details.Title,
-		Version:      mapVersion(details.Version),
-		Headings:     mapHeadings(details.HeadingsCounter),
-		Links:        mapLinks(details.Links),
-		HasLoginForm: details.HasLoginForm,
+	if details.Cached {
+		w.Header().Set("Age", strconv.Itoa(int(details.Age.Seconds())))
+	}
+	response := NewDetailsResponse(details)
+	if !options.sendsCredentials() {
+		response.ID = a.record(r.Context(), "", url, details)
 	}
 	err = json.NewEncoder(w).Encode(response)
 	if err != nil {
@@ -73,3 +146,198 @@ func (a *API) HTMLHandler(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusInternalServerError)
 	}
 }
+
+func (a *API) CreateJobHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Job request received")
+	w.Header().Set("Content-Type", "application/json")
+	url := r.FormValue("url")
+	if url == "" {
+		mapError(w, r, models.NewError(models.ErrTypeInvalidURL, "invalid url"))
+		return
+	}
+	options, err := a.parseAnalysisOptions(r)
+	if err != nil {
+		mapError(w, r, err)
+		return
+	}
+	submit := a.jobs.Submit
+	if options.sendsCredentials() {
+		submit = a.jobs.SubmitPrivate
+	}
+	job, err := submit(r.Context(), url, options.runAnalysis)
+	if err != nil {
+		fmt.Printf("jobs.Submit, url: %s, error: %s \n", url, err.Error())
+		mapJobError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/v1/analyzes/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	writeJob(w, job)
+}
+
+func (a *API) GetJobHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	job, err := a.jobs.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		mapJobError(w, r, err)
+		return
+	}
+	writeJob(w, job)
+}
+
+func (a *API) CancelJobHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	job, err := a.jobs.Cancel(r.Context(), r.PathValue("id"))
+	if err != nil {
+		mapJobError(w, r, err)
+		return
+	}
+	writeJob(w, job)
+}
+
+func writeJob(w http.ResponseWriter, job jobs.Job) {
+	err := json.NewEncoder(w).Encode(NewJobResponse(job))
+	if err != nil {
+		fmt.Println("failed to encode response: ", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func (o analysisOptions) runAnalysis(ctx context.Context, url string, progress func(verified, total int)) (*models.HTMLDetails, error) {
+	analyzer := o.newAnalyzer()
+	analyzer.WithContext(ctx)
+	analyzer.WithProgressFunc(progress)
+	return analyzer.RunFromURL(url)
+}
+
+func NewHTMLAnalyzer() *analyze.Analyzer {
+	analyzer := analyze.NewAnalyzer()
+	analyzer.WithRules(analyzer.BuiltinRules()...)
+	analyzer.WithRegistry(analyze.DefaultRegistry)
+	return analyzer
+}
+
+func (a *API) CrawlHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Crawl request received")
+	w.Header().Set("Content-Type", "application/json")
+	options, err := a.parseAnalysisOptions(r)
+	if err != nil {
+		mapError(w, r, err)
+		return
+	}
+	crawler, err := newCrawler(r, options)
+	if err != nil {
+		mapError(w, r, err)
+		return
+	}
+	url := r.FormValue("url")
+	result, err := crawler.Run(r.Context(), url)
+	if err != nil {
+		fmt.Printf("crawler.Run, url: %s, error: %s \n", url, err.Error())
+		mapError(w, r, err)
+		return
+	}
+	err = json.NewEncoder(w).Encode(NewCrawlResponse(result))
+	if err != nil {
+		fmt.Println("failed to encode response: ", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func newCrawler(r *http.Request, options analysisOptions) (*crawl.Crawler, error) {
+	crawler := crawl.NewCrawler(options.newAnalyzer)
+	if value := r.FormValue("max_depth"); value != "" {
+		maxDepth, err := strconv.Atoi(value)
+		if err != nil || maxDepth < 0 {
+			return nil, models.NewErrorWithStatusCode(models.ErrInvalidRequest, "invalid max_depth", http.StatusBadRequest)
+		}
+		crawler.WithMaxDepth(maxDepth)
+	}
+	if value := r.FormValue("max_pages"); value != "" {
+		maxPages, err := strconv.Atoi(value)
+		if err != nil || maxPages < 1 || maxPages > _maxCrawlPages {
+			return nil, models.NewErrorWithStatusCode(models.ErrInvalidRequest, "invalid max_pages", http.StatusBadRequest)
+		}
+		crawler.WithMaxPages(maxPages)
+	}
+	if value := r.FormValue("per_host_concurrency"); value != "" {
+		concurrency, err := strconv.Atoi(value)
+		if err != nil || concurrency < 1 {
+			return nil, models.NewErrorWithStatusCode(models.ErrInvalidRequest, "invalid per_host_concurrency", http.StatusBadRequest)
+		}
+		crawler.WithPerHostConcurrency(concurrency)
+	}
+	include, err := compilePatterns(r.Form["include"])
+	if err != nil {
+		return nil, err
+	}
+	crawler.WithInclude(include...)
+	exclude, err := compilePatterns(r.Form["exclude"])
+	if err != nil {
+		return nil, err
+	}
+	crawler.WithExclude(exclude...)
+	return crawler, nil
+}
+
+func (a *API) SitemapHandler(w http.ResponseWriter, r *http.Request) {
+	fmt.Println("Sitemap request received")
+	w.Header().Set("Content-Type", "application/json")
+	options, err := a.parseAnalysisOptions(r)
+	if err != nil {
+		mapError(w, r, err)
+		return
+	}
+	checker, err := newSitemapChecker(r, options)
+	if err != nil {
+		mapError(w, r, err)
+		return
+	}
+	url := r.FormValue("url")
+	result, err := checker.Run(r.Context(), url)
+	if err != nil {
+		fmt.Printf("checker.Run, url: %s, error: %s \n", url, err.Error())
+		mapError(w, r, err)
+		return
+	}
+	err = json.NewEncoder(w).Encode(NewSitemapResponse(result))
+	if err != nil {
+		fmt.Println("failed to encode response: ", err)
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+func newSitemapChecker(r *http.Request, options analysisOptions) (*sitemap.Checker, error) {
+	loader := sitemap.NewLoader(options.client)
+	if options.userAgent != "" {
+		loader.WithUserAgent(options.userAgent)
+	}
+	if value := r.FormValue("max_urls"); value != "" {
+		maxURLs, err := strconv.Atoi(value)
+		if err != nil || maxURLs < 1 || maxURLs > _maxSitemapURLs {
+			return nil, models.NewErrorWithStatusCode(models.ErrInvalidRequest, "invalid max_urls", http.StatusBadRequest)
+		}
+		loader.WithMaxURLs(maxURLs)
+	}
+	checker := sitemap.NewChecker(loader, options.newAnalyzer)
+	if value := r.FormValue("per_host_concurrency"); value != "" {
+		concurrency, err := strconv.Atoi(value)
+		if err != nil || concurrency < 1 {
+			return nil, models.NewErrorWithStatusCode(models.ErrInvalidRequest, "invalid per_host_concurrency", http.StatusBadRequest)
+		}
+		checker.WithPerHostConcurrency(concurrency)
+	}
+	return checker, nil
+}
+
+func compilePatterns(values []string) ([]*regexp.Regexp, error) {
+	var patterns []*regexp.Regexp
+	for _, value := range values {
+		pattern, err := regexp.Compile(value)
+		if err != nil {
+			return nil, models.NewErrorWithStatusCode(models.ErrInvalidRequest, "invalid pattern: "+value, http.StatusBadRequest)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
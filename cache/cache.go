@@ -0,0 +1,159 @@
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const _defaultMaxEntries = 1000
+
+type item[V any] struct {
+	value     V
+	storedAt  time.Time
+	expiresAt time.Time
+}
+
+// store is a map bounded to maxEntries. When it is full, the expired entries
+// are evicted first and then the oldest one.
+type store[V any] struct {
+	maxEntries int
+
+	mu    sync.Mutex
+	items map[string]item[V]
+}
+
+func newStore[V any]() store[V] {
+	return store[V]{
+		maxEntries: _defaultMaxEntries,
+		items:      make(map[string]item[V]),
+	}
+}
+
+func (s *store[V]) get(key string) (item[V], bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	it, ok := s.items[key]
+	return it, ok
+}
+
+func (s *store[V]) set(key string, it item[V]) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.items[key]; !ok && len(s.items) >= s.maxEntries {
+		s.evict(it.storedAt)
+	}
+	s.items[key] = it
+}
+
+func (s *store[V]) delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.items, key)
+}
+
+func (s *store[V]) evict(now time.Time) {
+	var (
+		oldestKey string
+		oldest    time.Time
+	)
+	for key, it := range s.items {
+		if now.After(it.expiresAt) {
+			delete(s.items, key)
+			continue
+		}
+		if oldestKey == "" || it.storedAt.Before(oldest) {
+			oldestKey, oldest = key, it.storedAt
+		}
+	}
+	if len(s.items) >= s.maxEntries {
+		delete(s.items, oldestKey)
+	}
+}
+
+// Key returns the cache key of rawURL analyzed with the given options. The URL
+// is normalized, so that "HTTP://Example.com:80/?b=2&a=1#top" and
+// "http://example.com/?a=1&b=2" share the same key. The key is hashed, as the
+// options may hold credentials.
+func Key(rawURL string, options ...string) string {
+	h := sha256.New()
+	h.Write([]byte(NormalizeURL(rawURL)))
+	for _, option := range options {
+		h.Write([]byte{0})
+		h.Write([]byte(option))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func NormalizeURL(rawURL string) string {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return rawURL
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		port = ""
+	}
+	u.Host = host
+	if port != "" {
+		u.Host += ":" + port
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.RawQuery = u.Query().Encode()
+	u.Fragment = ""
+	u.RawFragment = ""
+	return u.String()
+}
+
+// Expiry returns when a response with header stops being fresh, never later
+// than ttl from now. It returns false when the response must not be stored.
+// The s-maxage directive wins over max-age, which wins over Expires.
+func Expiry(header http.Header, now time.Time, ttl time.Duration) (time.Time, bool) {
+	lifetime := ttl
+	var maxAge, sharedMaxAge time.Duration = -1, -1
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		name, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		switch strings.ToLower(name) {
+		case "no-store":
+			return time.Time{}, false
+		case "no-cache":
+			lifetime = 0
+		case "max-age":
+			maxAge = parseSeconds(value)
+		case "s-maxage":
+			sharedMaxAge = parseSeconds(value)
+		}
+	}
+	switch {
+	case lifetime == 0:
+	case sharedMaxAge >= 0:
+		lifetime = min(sharedMaxAge, ttl)
+	case maxAge >= 0:
+		lifetime = min(maxAge, ttl)
+	case header.Get("Expires") != "":
+		expires, err := http.ParseTime(header.Get("Expires"))
+		if err != nil {
+			lifetime = 0
+			break
+		}
+		lifetime = max(0, min(expires.Sub(now), ttl))
+	}
+	return now.Add(lifetime), true
+}
+
+func parseSeconds(value string) time.Duration {
+	seconds, err := strconv.Atoi(strings.Trim(value, `"`))
+	if err != nil || seconds < 0 {
+		return -1
+	}
+	return time.Duration(seconds) * time.Second
+}
@@ -0,0 +1,136 @@
+package cache_test
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"github.com/danielperaltamadriz/html-analyzer/cache"
+	"github.com/stretchr/testify/suite"
+)
+
+type cacheTestSuite struct {
+	suite.Suite
+}
+
+func TestCacheSuite(t *testing.T) {
+	suite.Run(t, new(cacheTestSuite))
+}
+
+func (suite *cacheTestSuite) TestNormalizeURL() {
+	testCases := []struct {
+		url      string
+		expected string
+	}{
+		{url: "HTTP://Example.com:80", expected: "http://example.com/"},
+		{url: "https://example.com:443/page#top", expected: "https://example.com/page"},
+		{url: "https://example.com:8443/page?b=2&a=1", expected: "https://example.com:8443/page?a=1&b=2"},
+	}
+	for _, tc := range testCases {
+		suite.Run(tc.url, func() {
+			suite.Equal(tc.expected, cache.NormalizeURL(tc.url))
+		})
+	}
+}
+
+func (suite *cacheTestSuite) TestKey() {
+	suite.Equal(cache.Key("HTTP://Example.com/?b=2&a=1", "ua"), cache.Key("http://example.com/?a=1&b=2", "ua"))
+	suite.NotEqual(cache.Key("http://example.com/", "ua"), cache.Key("http://example.com/", "other"))
+	suite.NotContains(cache.Key("http://example.com/", "Authorization: secret"), "secret")
+}
+
+func (suite *cacheTestSuite) TestExpiry() {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	ttl := 10 * time.Minute
+	testCases := []struct {
+		name             string
+		header           http.Header
+		expectedLifetime time.Duration
+		expectedStore    bool
+	}{
+		{
+			name:             "default ttl",
+			header:           http.Header{},
+			expectedLifetime: ttl,
+			expectedStore:    true,
+		},
+		{
+			name:             "max-age",
+			header:           http.Header{"Cache-Control": {"public, max-age=60"}},
+			expectedLifetime: time.Minute,
+			expectedStore:    true,
+		},
+		{
+			name:             "max-age longer than the ttl",
+			header:           http.Header{"Cache-Control": {"max-age=86400"}},
+			expectedLifetime: ttl,
+			expectedStore:    true,
+		},
+		{
+			name:             "s-maxage wins over max-age",
+			header:           http.Header{"Cache-Control": {"max-age=60, s-maxage=120"}},
+			expectedLifetime: 2 * time.Minute,
+			expectedStore:    true,
+		},
+		{
+			name:             "expires",
+			header:           http.Header{"Expires": {now.Add(5 * time.Minute).Format(http.TimeFormat)}},
+			expectedLifetime: 5 * time.Minute,
+			expectedStore:    true,
+		},
+		{
+			name:             "no-cache",
+			header:           http.Header{"Cache-Control": {"no-cache"}},
+			expectedLifetime: 0,
+			expectedStore:    true,
+		},
+		{
+			name:          "no-store",
+			header:        http.Header{"Cache-Control": {"private, no-store"}},
+			expectedStore: false,
+		},
+	}
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			expiresAt, ok := cache.Expiry(tc.header, now, ttl)
+			suite.Equal(tc.expectedStore, ok)
+			if ok {
+				suite.Equal(tc.expectedLifetime, expiresAt.Sub(now))
+			}
+		})
+	}
+}
+
+func (suite *cacheTestSuite) TestResults() {
+	results := cache.NewResults()
+	now := time.Now()
+	results.Set("fresh", &cache.Result{Details: &models.HTMLDetails{Title: "fresh"}, StoredAt: now, ExpiresAt: now.Add(time.Minute)})
+	results.Set("stale", &cache.Result{Details: &models.HTMLDetails{Title: "stale"}, StoredAt: now, ExpiresAt: now})
+	results.Set("revalidatable", &cache.Result{Details: &models.HTMLDetails{Title: "revalidatable"}, ETag: `"v1"`, StoredAt: now, ExpiresAt: now})
+
+	result, ok := results.Get("fresh")
+	suite.Require().True(ok)
+	suite.True(result.Fresh(time.Now()))
+
+	_, ok = results.Get("stale")
+	suite.False(ok)
+
+	result, ok = results.Get("revalidatable")
+	suite.Require().True(ok)
+	suite.False(result.Fresh(time.Now()))
+}
+
+func (suite *cacheTestSuite) TestLinks() {
+	links := cache.NewLinks()
+	links.Set(models.Link{URL: "https://example.com/", Accessible: true, StatusCode: http.StatusOK})
+
+	link, ok := links.Get("https://example.com/")
+	suite.Require().True(ok)
+	suite.True(link.Accessible)
+
+	links.WithTTL(0)
+	links.Set(models.Link{URL: "https://example.com/expired"})
+	_, ok = links.Get("https://example.com/expired")
+	suite.False(ok)
+}
@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+)
+
+const _defaultLinksTTL = 5 * time.Minute
+
+// Links caches the verification of links, shared by all the analyses. Its
+// entries live less than the results, as the links of a page change more
+// often than the page itself.
+type Links struct {
+	ttl   time.Duration
+	store store[models.Link]
+}
+
+func NewLinks() *Links {
+	return &Links{
+		ttl:   _defaultLinksTTL,
+		store: newStore[models.Link](),
+	}
+}
+
+func (c *Links) WithTTL(ttl time.Duration) {
+	c.ttl = ttl
+}
+
+func (c *Links) Get(url string) (models.Link, bool) {
+	it, ok := c.store.get(url)
+	if !ok {
+		return models.Link{}, false
+	}
+	if !time.Now().Before(it.expiresAt) {
+		c.store.delete(url)
+		return models.Link{}, false
+	}
+	return it.value, true
+}
+
+func (c *Links) Set(link models.Link) {
+	now := time.Now()
+	c.store.set(link.URL, item[models.Link]{value: link, storedAt: now, expiresAt: now.Add(c.ttl)})
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+)
+
+const _defaultResultsTTL = 10 * time.Minute
+
+// Result is an analysis together with the page it was computed from, so that
+// a stale result can be revalidated with a conditional request.
+type Result struct {
+	Details      *models.HTMLDetails
+	Body         []byte
+	ContentType  string
+	ETag         string
+	LastModified string
+	StoredAt     time.Time
+	ExpiresAt    time.Time
+}
+
+func (r *Result) Fresh(now time.Time) bool {
+	return now.Before(r.ExpiresAt)
+}
+
+func (r *Result) Revalidatable() bool {
+	return r.ETag != "" || r.LastModified != ""
+}
+
+// Results caches the analyses of pages. Stale results are kept while they can
+// be revalidated.
+type Results struct {
+	ttl   time.Duration
+	store store[*Result]
+}
+
+func NewResults() *Results {
+	return &Results{
+		ttl:   _defaultResultsTTL,
+		store: newStore[*Result](),
+	}
+}
+
+// WithTTL sets the maximum time a result is fresh, even when the page allows
+// a longer one with Cache-Control.
+func (c *Results) WithTTL(ttl time.Duration) {
+	c.ttl = ttl
+}
+
+func (c *Results) TTL() time.Duration {
+	return c.ttl
+}
+
+func (c *Results) Get(key string) (*Result, bool) {
+	it, ok := c.store.get(key)
+	if !ok {
+		return nil, false
+	}
+	if !it.value.Fresh(time.Now()) && !it.value.Revalidatable() {
+		c.store.delete(key)
+		return nil, false
+	}
+	return it.value, true
+}
+
+func (c *Results) Delete(key string) {
+	c.store.delete(key)
+}
+
+func (c *Results) Set(key string, result *Result) {
+	c.store.set(key, item[*Result]{value: result, storedAt: result.StoredAt, expiresAt: result.ExpiresAt})
+}
@@ -5,13 +5,18 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/netip"
+	neturl "net/url"
 	"os"
+	"strings"
 	"testing"
 
+	"github.com/danielperaltamadriz/html-analyzer/analyze"
 	"github.com/danielperaltamadriz/html-analyzer/api"
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/ghttp"
+	"golang.org/x/net/html"
 )
 
 func TestAcceptanceTest(t *testing.T) {
@@ -19,12 +24,33 @@ func TestAcceptanceTest(t *testing.T) {
 	RunSpecs(t, "AcceptanceTest Suite")
 }
 
+// panicRule breaks the analysis of the pages with a <x-panic> element, like a
+// page the analyzer cannot handle.
+type panicRule struct{}
+
+func (panicRule) Name() string { return "panic" }
+
+func (panicRule) Visit(n *html.Node) bool {
+	if n.Type == html.ElementNode && n.Data == "x-panic" {
+		panic("unexpected page")
+	}
+	return false
+}
+
+func (panicRule) Result() any { return nil }
+
+func init() {
+	analyze.Register("panic", func() analyze.Rule { return panicRule{} })
+}
+
 var _ = Describe("Analyze HTML", func() {
 
 	var server *ghttp.Server
 	BeforeEach(func() {
 		server = ghttp.NewServer()
-		apiServer := api.NewAPI(api.APIConfig{})
+		apiServer := api.NewAPI(api.APIConfig{
+			AllowedNetworks: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
+		})
 		server.AppendHandlers(
 			apiServer.HTMLHandler,
 		)
@@ -35,20 +61,113 @@ var _ = Describe("Analyze HTML", func() {
 
 	Context("Given a not found URL", func() {
 		When("the HTML is requested", func() {
-			var statusCode int
+			var (
+				statusCode  int
+				url         string
+				errResponse api.ErrorResponse
+			)
 			BeforeEach(func() {
 				ts := httptestSetup(setupHTTPTest{
 					statusCode:   http.StatusNotFound,
 					htmlFilePath: "./testdata/file.html",
 				})
 				defer ts.Close()
+				url = ts.URL
 				resp, _ := http.Get(server.URL() + "?url=" + ts.URL)
 				statusCode = resp.StatusCode
+				defer resp.Body.Close()
+				Expect(json.NewDecoder(resp.Body).Decode(&errResponse)).To(Succeed())
 			})
 
 			It("should return a 404 status code", func() {
 				Expect(statusCode).To(Equal(http.StatusNotFound))
 			})
+
+			It("should return the error details", func() {
+				Expect(errResponse.Code).To(Equal(api.ErrCodeUpstreamStatus))
+				Expect(errResponse.Message).To(Equal("invalid status code: 404"))
+				Expect(errResponse.UpstreamStatus).To(Equal(http.StatusNotFound))
+				Expect(errResponse.URL).To(Equal(url))
+				Expect(errResponse.RequestID).NotTo(BeEmpty())
+			})
+		})
+	})
+
+	Context("Given a URL failing with a server error", func() {
+		When("the HTML is requested", func() {
+			var (
+				statusCode  int
+				errResponse api.ErrorResponse
+			)
+			BeforeEach(func() {
+				ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.URL.Path == "/robots.txt" {
+						http.NotFound(w, r)
+						return
+					}
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}))
+				defer ts.Close()
+				req, err := http.NewRequest(http.MethodGet, server.URL()+"?url="+ts.URL, nil)
+				Expect(err).To(BeNil())
+				req.Header.Set("X-Request-ID", "request-1")
+				resp, err := http.DefaultClient.Do(req)
+				Expect(err).To(BeNil())
+				statusCode = resp.StatusCode
+				defer resp.Body.Close()
+				Expect(json.NewDecoder(resp.Body).Decode(&errResponse)).To(Succeed())
+			})
+
+			It("should return a 502 status code", func() {
+				Expect(statusCode).To(Equal(http.StatusBadGateway))
+			})
+
+			It("should return the upstream status and the request id", func() {
+				Expect(errResponse.UpstreamStatus).To(Equal(http.StatusServiceUnavailable))
+				Expect(errResponse.RequestID).To(Equal("request-1"))
+			})
+		})
+	})
+
+	Context("Given a non HTML URL", func() {
+		When("the HTML is requested", func() {
+			var statusCode int
+			BeforeEach(func() {
+				ts := httptestSetup(setupHTTPTest{
+					statusCode:   http.StatusOK,
+					htmlFilePath: "./testdata/file.json",
+				})
+				defer ts.Close()
+				resp, _ := http.Get(server.URL() + "?url=" + ts.URL)
+				statusCode = resp.StatusCode
+			})
+
+			It("should return a 415 status code", func() {
+				Expect(statusCode).To(Equal(http.StatusUnsupportedMediaType))
+			})
+		})
+	})
+
+	Context("Given a private network URL", func() {
+		When("the HTML is requested", func() {
+			var statusCode int
+			BeforeEach(func() {
+				ts := httptestSetup(setupHTTPTest{
+					statusCode:   http.StatusOK,
+					htmlFilePath: "./testdata/file.html",
+				})
+				defer ts.Close()
+				blockedServer := ghttp.NewServer()
+				defer blockedServer.Close()
+				blockedServer.AppendHandlers(api.NewAPI(api.APIConfig{}).HTMLHandler)
+				resp, err := http.Get(blockedServer.URL() + "?url=" + ts.URL)
+				Expect(err).To(BeNil())
+				statusCode = resp.StatusCode
+			})
+
+			It("should return a 403 status code", func() {
+				Expect(statusCode).To(Equal(http.StatusForbidden))
+			})
 		})
 	})
 
@@ -118,6 +237,11 @@ var _ = Describe("Analyze HTML", func() {
 				})))
 			})
 
+			It("should return the links by kind", func() {
+				Expect(details.Links.ByKind["anchor"].Total).To(Equal(4))
+				Expect(details.Links.ByKind["form"].Total).To(Equal(1))
+			})
+
 			It("should return the external links counter", func() {
 				Expect(details.Links.External.Total).To(Equal(2))
 				Expect(mapLinkDetailsToMap(details.Links.External.LinkDetails)).To(Equal(mapLinkDetailsToMap([]api.LinkDetailResponse{
@@ -158,12 +282,287 @@ var _ = Describe("Analyze HTML", func() {
 			})
 		})
 	})
+
+	Context("Given a sitemap", func() {
+		When("the sitemap is analyzed", func() {
+			var (
+				siteURL  string
+				response api.SitemapResponse
+			)
+			BeforeEach(func() {
+				var ts *httptest.Server
+				ts = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					switch r.URL.Path {
+					case "/sitemap.xml":
+						w.Write([]byte("<urlset><url><loc>" + ts.URL + "/page</loc></url><url><loc>" + ts.URL + "/missing</loc></url></urlset>")) // nolint: errcheck
+					case "/page":
+						w.Write([]byte(`<html><head><title>Page</title><link rel="canonical" href="/other"></head></html>`)) // nolint: errcheck
+					default:
+						http.NotFound(w, r)
+					}
+				}))
+				defer ts.Close()
+				siteURL = ts.URL
+
+				apiServer := api.NewAPI(api.APIConfig{
+					AllowedNetworks: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
+				})
+				server.SetHandler(0, apiServer.SitemapHandler)
+				resp, err := http.Get(server.URL() + "?url=" + ts.URL + "/sitemap.xml")
+				Expect(err).To(BeNil())
+				defer resp.Body.Close()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(json.NewDecoder(resp.Body).Decode(&response)).To(Succeed())
+			})
+
+			It("should return the analysis of every listed page", func() {
+				Expect(response.Pages).To(HaveLen(2))
+				Expect(response.Pages[0].Details.Title).To(Equal("Page"))
+			})
+
+			It("should report the non 200 pages and the canonicals pointing elsewhere", func() {
+				Expect(response.Issues).To(ConsistOf(
+					api.SitemapIssueResponse{URL: siteURL + "/page", Type: "canonical_mismatch", Target: siteURL + "/other"},
+					api.SitemapIssueResponse{URL: siteURL + "/missing", Type: "non_200", StatusCode: http.StatusNotFound, Message: "invalid status code: 404"},
+				))
+			})
+		})
+	})
+
+	Context("Given a sitemap that is not valid XML", func() {
+		When("the sitemap is analyzed", func() {
+			var (
+				statusCode  int
+				errResponse api.ErrorResponse
+			)
+			BeforeEach(func() {
+				ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte("<urlset><url><loc>")) // nolint: errcheck
+				}))
+				defer ts.Close()
+
+				apiServer := api.NewAPI(api.APIConfig{
+					AllowedNetworks: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
+				})
+				server.SetHandler(0, apiServer.SitemapHandler)
+				resp, err := http.Get(server.URL() + "?url=" + ts.URL + "/sitemap.xml")
+				Expect(err).To(BeNil())
+				defer resp.Body.Close()
+				statusCode = resp.StatusCode
+				Expect(json.NewDecoder(resp.Body).Decode(&errResponse)).To(Succeed())
+			})
+
+			It("should return a 502 status code", func() {
+				Expect(statusCode).To(Equal(http.StatusBadGateway))
+				Expect(errResponse.Code).To(Equal(api.ErrCodeInvalidResponse))
+			})
+		})
+	})
+
+	Context("Given a batch of URLs", func() {
+		When("the batch is analyzed", func() {
+			var (
+				contentType string
+				results     map[string]api.BatchResultResponse
+				found       string
+				missing     string
+				broken      string
+			)
+			BeforeEach(func() {
+				foundTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte("<html><head><title>Batch page</title></head><body></body></html>")) // nolint: errcheck
+				}))
+				defer foundTS.Close()
+				missingTS := httptestSetup(setupHTTPTest{
+					statusCode:   http.StatusNotFound,
+					htmlFilePath: "./testdata/file.html",
+				})
+				defer missingTS.Close()
+				brokenTS := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					w.Write([]byte("<html><head><title>Broken page</title></head><body><x-panic></x-panic></body></html>")) // nolint: errcheck
+				}))
+				defer brokenTS.Close()
+				found, missing, broken = foundTS.URL, missingTS.URL, brokenTS.URL
+
+				apiServer := api.NewAPI(api.APIConfig{
+					AllowedNetworks: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
+				})
+				server.SetHandler(0, apiServer.BatchHandler)
+				resp, err := http.Post(server.URL()+"?workers=2", "text/plain", strings.NewReader(found+"\n\n"+missing+"\n"+broken+"\n"))
+				Expect(err).To(BeNil())
+				defer resp.Body.Close()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				contentType = resp.Header.Get("Content-Type")
+
+				results = make(map[string]api.BatchResultResponse)
+				decoder := json.NewDecoder(resp.Body)
+				for decoder.More() {
+					var result api.BatchResultResponse
+					Expect(decoder.Decode(&result)).To(Succeed())
+					results[result.URL] = result
+				}
+			})
+
+			It("should stream a result per URL as NDJSON", func() {
+				Expect(contentType).To(Equal("application/x-ndjson"))
+				Expect(results).To(HaveLen(3))
+			})
+
+			It("should return the details of the analyzed pages", func() {
+				Expect(results[found].Index).To(Equal(0))
+				Expect(results[found].StatusCode).To(Equal(http.StatusOK))
+				Expect(results[found].Details.Title).To(Equal("Batch page"))
+			})
+
+			It("should return the error of the failed pages", func() {
+				Expect(results[missing].Index).To(Equal(1))
+				Expect(results[missing].StatusCode).To(Equal(http.StatusNotFound))
+				Expect(results[missing].Details).To(BeNil())
+				Expect(results[missing].Error.Code).To(Equal(api.ErrCodeUpstreamStatus))
+				Expect(results[missing].Error.URL).To(Equal(missing))
+			})
+
+			It("should return the error of the pages breaking the analyzer", func() {
+				Expect(results[broken].Index).To(Equal(2))
+				Expect(results[broken].StatusCode).To(Equal(http.StatusInternalServerError))
+				Expect(results[broken].Error.Code).To(Equal(api.ErrCodeInternal))
+				Expect(results[broken].Error.URL).To(Equal(broken))
+			})
+		})
+	})
+
+	Context("Given a page analyzed twice", func() {
+		When("the history and the diff are requested", func() {
+			var (
+				historyResponse api.HistoryResponse
+				diffResponse    api.DiffResponse
+			)
+			BeforeEach(func() {
+				titles := []string{"First title", "Second title"}
+				requests := 0
+				ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.URL.Path == "/robots.txt" {
+						http.NotFound(w, r)
+						return
+					}
+					w.Write([]byte("<html><head><title>" + titles[requests%len(titles)] + "</title></head><body></body></html>")) // nolint: errcheck
+					requests++
+				}))
+				defer ts.Close()
+
+				apiServer := api.NewAPI(api.APIConfig{
+					AllowedNetworks: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
+				})
+				mux := http.NewServeMux()
+				mux.HandleFunc("GET /v1/analyzes", apiServer.HTMLHandler)
+				mux.HandleFunc("GET /v1/analyzes/{id}/diff/{otherId}", apiServer.DiffHandler)
+				mux.HandleFunc("GET /v1/urls/{url}/history", apiServer.HistoryHandler)
+				apiTS := httptest.NewServer(mux)
+				defer apiTS.Close()
+
+				for _, query := range []string{"?url=" + ts.URL, "?cache=bypass&url=" + ts.URL} {
+					resp, err := http.Get(apiTS.URL + "/v1/analyzes" + query)
+					Expect(err).To(BeNil())
+					resp.Body.Close()
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				}
+
+				resp, err := http.Get(apiTS.URL + "/v1/urls/" + neturl.PathEscape(ts.URL) + "/history")
+				Expect(err).To(BeNil())
+				defer resp.Body.Close()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(json.NewDecoder(resp.Body).Decode(&historyResponse)).To(Succeed())
+				Expect(historyResponse.Analyses).To(HaveLen(2))
+
+				resp, err = http.Get(apiTS.URL + "/v1/analyzes/" + historyResponse.Analyses[1].ID + "/diff/" + historyResponse.Analyses[0].ID)
+				Expect(err).To(BeNil())
+				defer resp.Body.Close()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(json.NewDecoder(resp.Body).Decode(&diffResponse)).To(Succeed())
+			})
+
+			It("should return the analyses, most recent first", func() {
+				Expect(historyResponse.Analyses[0].Details.Title).To(Equal("Second title"))
+				Expect(historyResponse.Analyses[1].Details.Title).To(Equal("First title"))
+			})
+
+			It("should return the title change", func() {
+				Expect(diffResponse.Title).To(Equal(&api.ChangeResponse[string]{From: "First title", To: "Second title"}))
+				Expect(diffResponse.LoginForm).To(BeNil())
+			})
+		})
+
+		When("the analyses are of different pages or sent with credentials", func() {
+			var (
+				historyResponse api.HistoryResponse
+				diffStatusCode  int
+				credentialsID   string
+			)
+			BeforeEach(func() {
+				ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					if r.URL.Path == "/robots.txt" {
+						http.NotFound(w, r)
+						return
+					}
+					w.Write([]byte("<html><head><title>" + r.URL.Path + "</title></head><body></body></html>")) // nolint: errcheck
+				}))
+				defer ts.Close()
+
+				apiServer := api.NewAPI(api.APIConfig{
+					AllowedNetworks: []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")},
+				})
+				mux := http.NewServeMux()
+				mux.HandleFunc("GET /v1/analyzes", apiServer.HTMLHandler)
+				mux.HandleFunc("GET /v1/analyzes/{id}/diff/{otherId}", apiServer.DiffHandler)
+				mux.HandleFunc("GET /v1/urls/{url}/history", apiServer.HistoryHandler)
+				apiTS := httptest.NewServer(mux)
+				defer apiTS.Close()
+
+				var ids []string
+				for _, query := range []string{"?url=" + ts.URL + "/a", "?url=" + ts.URL + "/b", "?cache=bypass&header=Authorization:+Bearer+secret&url=" + ts.URL + "/a"} {
+					resp, err := http.Get(apiTS.URL + "/v1/analyzes" + query)
+					Expect(err).To(BeNil())
+					var details api.DetailsResponse
+					Expect(json.NewDecoder(resp.Body).Decode(&details)).To(Succeed())
+					resp.Body.Close()
+					Expect(resp.StatusCode).To(Equal(http.StatusOK))
+					ids = append(ids, details.ID)
+				}
+				credentialsID = ids[2]
+
+				resp, err := http.Get(apiTS.URL + "/v1/urls/" + neturl.PathEscape(strings.ToUpper(ts.URL[:4])+ts.URL[4:]+"/a") + "/history")
+				Expect(err).To(BeNil())
+				defer resp.Body.Close()
+				Expect(resp.StatusCode).To(Equal(http.StatusOK))
+				Expect(json.NewDecoder(resp.Body).Decode(&historyResponse)).To(Succeed())
+
+				resp, err = http.Get(apiTS.URL + "/v1/analyzes/" + ids[0] + "/diff/" + ids[1])
+				Expect(err).To(BeNil())
+				resp.Body.Close()
+				diffStatusCode = resp.StatusCode
+			})
+
+			It("should not record the analysis sent with credentials", func() {
+				Expect(credentialsID).To(BeEmpty())
+				Expect(historyResponse.Analyses).To(HaveLen(1))
+				Expect(historyResponse.Analyses[0].Details.Title).To(Equal("/a"))
+			})
+
+			It("should refuse to compare analyses of different pages", func() {
+				Expect(diffStatusCode).To(Equal(http.StatusBadRequest))
+			})
+		})
+	})
 })
 
 func mapLinkDetailsToMap(links []api.LinkDetailResponse) map[string]api.LinkDetailResponse {
 	linkDetails := make(map[string]api.LinkDetailResponse)
 	for _, link := range links {
-		linkDetails[link.URL] = link
+		linkDetails[link.URL] = api.LinkDetailResponse{
+			URL:          link.URL,
+			Count:        link.Count,
+			IsAccessible: link.IsAccessible,
+		}
 	}
 	return linkDetails
 }
@@ -4,15 +4,45 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"os"
 	"os/signal"
+	"strings"
 	"sync"
 	"syscall"
+	"time"
 
 	"github.com/danielperaltamadriz/html-analyzer/api"
+	"github.com/danielperaltamadriz/html-analyzer/history"
+	"github.com/danielperaltamadriz/html-analyzer/netguard"
 )
 
 func main() {
-	server := api.NewAPI(api.APIConfig{})
+	allowedNetworks, err := netguard.ParsePrefixes(strings.Split(os.Getenv("ALLOWED_NETWORKS"), ","))
+	if err != nil {
+		log.Fatal("Invalid ALLOWED_NETWORKS: ", err)
+	}
+	cacheTTL, err := parseDuration(os.Getenv("CACHE_TTL"))
+	if err != nil {
+		log.Fatal("Invalid CACHE_TTL: ", err)
+	}
+	linkCacheTTL, err := parseDuration(os.Getenv("LINK_CACHE_TTL"))
+	if err != nil {
+		log.Fatal("Invalid LINK_CACHE_TTL: ", err)
+	}
+	cfg := api.APIConfig{
+		AllowedNetworks: allowedNetworks,
+		CacheTTL:        cacheTTL,
+		LinkCacheTTL:    linkCacheTTL,
+	}
+	if path := os.Getenv("HISTORY_DB"); path != "" {
+		store, err := history.NewSQLiteStore(path)
+		if err != nil {
+			log.Fatal("Failed to open HISTORY_DB: ", err)
+		}
+		defer store.Close()
+		cfg.History = store
+	}
+	server := api.NewAPI(cfg)
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 	var wg sync.WaitGroup
@@ -28,10 +58,17 @@ func main() {
 
 	<-ctx.Done()
 
-	err := server.Shutdown()
+	err = server.Shutdown()
 	if err != nil {
 		log.Fatal("Failed to stop server: ", err)
 	}
 	wg.Wait()
 	fmt.Println("Server stopped")
 }
+
+func parseDuration(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+	return time.ParseDuration(value)
+}
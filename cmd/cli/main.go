@@ -0,0 +1,237 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze"
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"github.com/danielperaltamadriz/html-analyzer/api"
+	"github.com/danielperaltamadriz/html-analyzer/robots"
+)
+
+const (
+	exitOK = iota
+	exitUnknownError
+	exitUsage
+	exitInvalidURL
+	exitInvalidRequest
+	exitInvalidResponse
+	exitRobotsDisallowed
+	exitUnsupportedContent
+	exitTimeout
+	exitSitemapIssues
+)
+
+type config struct {
+	format    string
+	baseURL   string
+	userAgent string
+	headers   headerFlag
+	client    analyze.HTTPClientConfig
+	robots    bool
+	skipLinks bool
+	targets   []string
+
+	maxURLs            int
+	perHostConcurrency int
+}
+
+type headerFlag []string
+
+func (h *headerFlag) String() string {
+	return strings.Join(*h, ", ")
+}
+
+func (h *headerFlag) Set(value string) error {
+	if !strings.Contains(value, ":") {
+		return errors.New(`header must have the format "Name: value"`)
+	}
+	*h = append(*h, value)
+	return nil
+}
+
+type result struct {
+	Target  string               `json:"target"`
+	Details *api.DetailsResponse `json:"details,omitempty"`
+	Error   string               `json:"error,omitempty"`
+
+	err error
+}
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	if len(args) > 0 && args[0] == "sitemap" {
+		return runSitemap(args[1:], stdout, stderr)
+	}
+	cfg, err := parseFlags(args, stderr)
+	if err != nil {
+		return exitUsage
+	}
+
+	client, robotsCache, err := newClient(cfg)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	results := make([]result, 0, len(cfg.targets))
+	for _, target := range cfg.targets {
+		results = append(results, analyzeTarget(cfg, client, robotsCache, target))
+	}
+
+	if err := writeResults(stdout, cfg.format, results); err != nil {
+		fmt.Fprintln(stderr, "failed to write results:", err)
+		return exitUnknownError
+	}
+
+	for _, r := range results {
+		if r.err != nil {
+			return exitCode(r.err)
+		}
+	}
+	return exitOK
+}
+
+func parseFlags(args []string, stderr io.Writer) (config, error) {
+	var cfg config
+	fs := newFlagSet(&cfg, stderr, "Usage: html-analyzer [flags] <url|file>...\n       html-analyzer sitemap [flags] <sitemap url>")
+	fs.StringVar(&cfg.baseURL, "base-url", "", "base URL used to resolve links of local files")
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+	cfg.targets = fs.Args()
+	if len(cfg.targets) == 0 {
+		fs.Usage()
+		return cfg, errors.New("missing url or file")
+	}
+	return cfg, validateFormat(cfg.format, stderr)
+}
+
+// newFlagSet returns the flags shared by the analysis of pages and sitemaps.
+func newFlagSet(cfg *config, stderr io.Writer, usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet("html-analyzer", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintln(stderr, usage)
+		fs.PrintDefaults()
+	}
+	fs.StringVar(&cfg.format, "format", formatJSON, "output format: json, table or csv")
+	fs.StringVar(&cfg.userAgent, "user-agent", "", "User-Agent sent with every request")
+	fs.Var(&cfg.headers, "header", `header sent with every request, as "Name: value" (repeatable)`)
+	fs.StringVar(&cfg.client.ProxyURL, "proxy", "", "proxy URL")
+	fs.StringVar(&cfg.client.CAFile, "ca-file", "", "PEM file with additional certificate authorities")
+	fs.StringVar(&cfg.client.CertFile, "cert-file", "", "PEM client certificate")
+	fs.StringVar(&cfg.client.KeyFile, "key-file", "", "PEM client certificate key")
+	fs.BoolVar(&cfg.robots, "robots", false, "respect robots.txt and its crawl delay")
+	fs.BoolVar(&cfg.skipLinks, "skip-disallowed-links", false, "skip the verification of links disallowed by robots.txt (requires -robots)")
+	return fs
+}
+
+func validateFormat(format string, stderr io.Writer) error {
+	switch format {
+	case formatJSON, formatTable, formatCSV:
+		return nil
+	}
+	fmt.Fprintf(stderr, "invalid format: %s\n", format)
+	return errors.New("invalid format")
+}
+
+func newClient(cfg config) (*http.Client, *robots.Cache, error) {
+	client, err := analyze.NewHTTPClient(cfg.client)
+	if err != nil {
+		return nil, nil, err
+	}
+	var robotsCache *robots.Cache
+	if cfg.robots {
+		userAgent := cfg.userAgent
+		if userAgent == "" {
+			userAgent = analyze.DefaultUserAgent
+		}
+		robotsCache = robots.NewCache(client, userAgent)
+	}
+	return client, robotsCache, nil
+}
+
+func newAnalyzer(cfg config, client *http.Client, robotsCache *robots.Cache) *analyze.Analyzer {
+	analyzer := api.NewHTMLAnalyzer()
+	analyzer.WithHTTPClient(client)
+	analyzer.WithUserAgent(cfg.userAgent)
+	analyzer.WithHeaders(cfg.headers.header())
+	if robotsCache != nil {
+		analyzer.WithRobots(robotsCache, cfg.skipLinks)
+	}
+	return analyzer
+}
+
+func analyzeTarget(cfg config, client *http.Client, robotsCache *robots.Cache, target string) result {
+	analyzer := newAnalyzer(cfg, client, robotsCache)
+	var (
+		details *models.HTMLDetails
+		err     error
+	)
+	if isURL(target) {
+		details, err = analyzer.RunFromURL(target)
+	} else {
+		details, err = analyzeFile(analyzer, target, cfg.baseURL)
+	}
+	if err != nil {
+		return result{Target: target, Error: err.Error(), err: err}
+	}
+	response := api.NewDetailsResponse(details)
+	return result{Target: target, Details: &response}
+}
+
+func analyzeFile(analyzer *analyze.Analyzer, path, baseURL string) (*models.HTMLDetails, error) {
+	file, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, models.NewError(models.ErrTypeInvalidURL, fmt.Sprintf("failed to open file: %s", err))
+	}
+	defer file.Close()
+	return analyzer.RunFromReader(context.Background(), file, baseURL)
+}
+
+func (h headerFlag) header() http.Header {
+	header := make(http.Header)
+	for _, value := range h {
+		key, val, _ := strings.Cut(value, ":")
+		header.Add(strings.TrimSpace(key), strings.TrimSpace(val))
+	}
+	return header
+}
+
+func isURL(target string) bool {
+	return strings.HasPrefix(target, "http://") || strings.HasPrefix(target, "https://")
+}
+
+func exitCode(err error) int {
+	var e *models.Error
+	if !errors.As(err, &e) {
+		return exitUnknownError
+	}
+	switch e.Type {
+	case models.ErrTypeInvalidURL:
+		return exitInvalidURL
+	case models.ErrInvalidRequest, models.ErrTypeUpstreamStatus:
+		return exitInvalidRequest
+	case models.ErrTypeInvalidResponse:
+		return exitInvalidResponse
+	case models.ErrTypeRobotsDisallowed:
+		return exitRobotsDisallowed
+	case models.ErrTypeUnsupportedContent:
+		return exitUnsupportedContent
+	case models.ErrTypeTimeout:
+		return exitTimeout
+	}
+	return exitUnknownError
+}
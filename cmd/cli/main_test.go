@@ -0,0 +1,214 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"github.com/danielperaltamadriz/html-analyzer/api"
+	"github.com/stretchr/testify/suite"
+)
+
+type cliTestSuite struct {
+	suite.Suite
+}
+
+func TestCLISuite(t *testing.T) {
+	suite.Run(t, new(cliTestSuite))
+}
+
+func (suite *cliTestSuite) TestExitCode() {
+	testCases := []struct {
+		name string
+		err  error
+
+		expected int
+	}{
+		{name: "invalid url", err: models.NewError(models.ErrTypeInvalidURL, "invalid url"), expected: exitInvalidURL},
+		{name: "invalid request", err: models.NewError(models.ErrInvalidRequest, "invalid request"), expected: exitInvalidRequest},
+		{name: "upstream status", err: models.NewErrorWithStatusCode(models.ErrTypeUpstreamStatus, "invalid status code: 404", 404), expected: exitInvalidRequest},
+		{name: "invalid response", err: models.NewError(models.ErrTypeInvalidResponse, "invalid response"), expected: exitInvalidResponse},
+		{name: "robots disallowed", err: models.NewError(models.ErrTypeRobotsDisallowed, "disallowed by robots.txt"), expected: exitRobotsDisallowed},
+		{name: "unsupported content", err: models.NewError(models.ErrTypeUnsupportedContent, "invalid content type: application/json"), expected: exitUnsupportedContent},
+		{name: "timeout", err: models.NewError(models.ErrTypeTimeout, "timed out"), expected: exitTimeout},
+		{name: "wrapped error", err: fmt.Errorf("doRequest: %w", models.NewError(models.ErrTypeTimeout, "timed out")), expected: exitTimeout},
+		{name: "unknown error", err: errors.New("boom"), expected: exitUnknownError},
+	}
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			suite.Equal(tc.expected, exitCode(tc.err))
+		})
+	}
+}
+
+func (suite *cliTestSuite) TestWriteResults() {
+	results := []result{
+		{
+			Target: "https://example.com",
+			Details: &api.DetailsResponse{
+				Title:        "Example, Inc.",
+				Version:      &api.VersionResponse{Name: "HTML 5"},
+				Headings:     api.HeadingResponse{H1: 1, H2: 2},
+				Links:        api.LinksResponse{Internal: api.LinkTypeResponse{Total: 3, TotalInaccessible: 1}, External: api.LinkTypeResponse{Total: 2}},
+				HasLoginForm: true,
+			},
+		},
+		{Target: "./missing.html", Error: "failed to open file"},
+	}
+	testCases := []struct {
+		name   string
+		format string
+
+		expected string
+	}{
+		{
+			name:   "table",
+			format: formatTable,
+			expected: "TARGET               VERSION  TITLE          H1  H2  H3  H4  H5  H6  INTERNAL_LINKS  EXTERNAL_LINKS  INACCESSIBLE_LINKS  HAS_LOGIN_FORM  ERROR\n" +
+				"https://example.com  HTML 5   Example, Inc.  1   2   0   0   0   0   3               2               1                   true            \n" +
+				"./missing.html                                                                                                                           failed to open file\n",
+		},
+		{
+			name:   "csv",
+			format: formatCSV,
+			expected: "target,version,title,h1,h2,h3,h4,h5,h6,internal_links,external_links,inaccessible_links,has_login_form,error\n" +
+				"https://example.com,HTML 5,\"Example, Inc.\",1,2,0,0,0,0,3,2,1,true,\n" +
+				"./missing.html,,,,,,,,,,,,,failed to open file\n",
+		},
+	}
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			var buf bytes.Buffer
+			suite.Require().NoError(writeResults(&buf, tc.format, results))
+			suite.Equal(tc.expected, buf.String())
+		})
+	}
+
+	suite.Run("json", func() {
+		var buf bytes.Buffer
+		suite.Require().NoError(writeResults(&buf, formatJSON, results))
+		var decoded []result
+		suite.Require().NoError(json.Unmarshal(buf.Bytes(), &decoded))
+		suite.Equal(results, decoded)
+	})
+}
+
+func (suite *cliTestSuite) TestParseFlags() {
+	testCases := []struct {
+		name string
+		args []string
+
+		expectedErr     bool
+		expectedTargets []string
+		expectedFormat  string
+	}{
+		{
+			name:            "default format",
+			args:            []string{"https://example.com", "./page.html"},
+			expectedTargets: []string{"https://example.com", "./page.html"},
+			expectedFormat:  formatJSON,
+		},
+		{
+			name:            "csv format",
+			args:            []string{"-format", "csv", "https://example.com"},
+			expectedTargets: []string{"https://example.com"},
+			expectedFormat:  formatCSV,
+		},
+		{
+			name:        "missing target",
+			args:        []string{"-format", "table"},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid format",
+			args:        []string{"-format", "xml", "https://example.com"},
+			expectedErr: true,
+		},
+		{
+			name:        "invalid header",
+			args:        []string{"-header", "no-colon", "https://example.com"},
+			expectedErr: true,
+		},
+	}
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			cfg, err := parseFlags(tc.args, io.Discard)
+			if tc.expectedErr {
+				suite.Error(err)
+				return
+			}
+			suite.Require().NoError(err)
+			suite.Equal(tc.expectedTargets, cfg.targets)
+			suite.Equal(tc.expectedFormat, cfg.format)
+		})
+	}
+}
+
+func (suite *cliTestSuite) TestRun() {
+	page := filepath.Join(suite.T().TempDir(), "page.html")
+	suite.Require().NoError(os.WriteFile(page, []byte(`<html><head><title>Local page</title></head><body><h1>Hello</h1></body></html>`), 0o600))
+
+	testCases := []struct {
+		name string
+		args []string
+
+		expectedCode int
+	}{
+		{name: "local file", args: []string{"-format", "csv", page}, expectedCode: exitOK},
+		{name: "missing file", args: []string{page + ".missing"}, expectedCode: exitInvalidURL},
+		{name: "usage", args: []string{}, expectedCode: exitUsage},
+	}
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			var stdout bytes.Buffer
+			suite.Equal(tc.expectedCode, run(tc.args, &stdout, io.Discard))
+		})
+	}
+}
+
+func (suite *cliTestSuite) TestRunSitemap() {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok.xml":
+			fmt.Fprintf(w, `<urlset><url><loc>%s/ok</loc></url></urlset>`, server.URL)
+		case "/moved.xml":
+			fmt.Fprintf(w, `<urlset><url><loc>%s/ok</loc></url><url><loc>%s/moved</loc></url></urlset>`, server.URL, server.URL)
+		case "/missing.xml":
+			fmt.Fprintf(w, `<urlset><url><loc>%s/missing</loc></url><url><loc>%s/moved</loc></url></urlset>`, server.URL, server.URL)
+		case "/ok":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><head><title>ok</title></head></html>`)) // nolint: errcheck
+		case "/moved":
+			http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	testCases := []struct {
+		name    string
+		sitemap string
+
+		expectedCode int
+	}{
+		{name: "no issues", sitemap: "/ok.xml", expectedCode: exitOK},
+		{name: "issues", sitemap: "/moved.xml", expectedCode: exitSitemapIssues},
+		{name: "failed page", sitemap: "/missing.xml", expectedCode: exitInvalidRequest},
+	}
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			var stdout bytes.Buffer
+			suite.Equal(tc.expectedCode, run([]string{"sitemap", server.URL + tc.sitemap}, &stdout, io.Discard))
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	formatJSON  = "json"
+	formatTable = "table"
+	formatCSV   = "csv"
+)
+
+var columns = []string{
+	"target", "version", "title",
+	"h1", "h2", "h3", "h4", "h5", "h6",
+	"internal_links", "external_links", "inaccessible_links",
+	"has_login_form", "error",
+}
+
+func writeResults(w io.Writer, format string, results []result) error {
+	switch format {
+	case formatTable:
+		return writeTable(w, results)
+	case formatCSV:
+		return writeCSV(w, results)
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(results)
+}
+
+func writeTable(w io.Writer, results []result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, strings.ToUpper(strings.Join(columns, "\t")))
+	for _, r := range results {
+		fmt.Fprintln(tw, strings.Join(row(r), "\t"))
+	}
+	return tw.Flush()
+}
+
+func writeCSV(w io.Writer, results []result) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(columns); err != nil {
+		return err
+	}
+	for _, r := range results {
+		if err := cw.Write(row(r)); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func row(r result) []string {
+	if r.Details == nil {
+		values := make([]string, len(columns))
+		values[0] = r.Target
+		values[len(values)-1] = r.Error
+		return values
+	}
+	d := r.Details
+	var version string
+	if d.Version != nil {
+		version = d.Version.Name
+	}
+	return []string{
+		r.Target,
+		version,
+		d.Title,
+		strconv.Itoa(d.Headings.H1),
+		strconv.Itoa(d.Headings.H2),
+		strconv.Itoa(d.Headings.H3),
+		strconv.Itoa(d.Headings.H4),
+		strconv.Itoa(d.Headings.H5),
+		strconv.Itoa(d.Headings.H6),
+		strconv.Itoa(d.Links.Internal.Total),
+		strconv.Itoa(d.Links.External.Total),
+		strconv.Itoa(d.Links.Internal.TotalInaccessible + d.Links.External.TotalInaccessible),
+		strconv.FormatBool(d.HasLoginForm),
+		r.Error,
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze"
+	"github.com/danielperaltamadriz/html-analyzer/api"
+	"github.com/danielperaltamadriz/html-analyzer/sitemap"
+)
+
+var issueColumns = []string{"url", "type", "status_code", "target", "message"}
+
+// runSitemap analyzes every page of a sitemap. The json output holds the
+// analyses and the issues, the table and csv outputs only the issues.
+func runSitemap(args []string, stdout, stderr io.Writer) int {
+	cfg, err := parseSitemapFlags(args, stderr)
+	if err != nil {
+		return exitUsage
+	}
+
+	client, robotsCache, err := newClient(cfg)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+
+	loader := sitemap.NewLoader(client)
+	if cfg.userAgent != "" {
+		loader.WithUserAgent(cfg.userAgent)
+	}
+	loader.WithMaxURLs(cfg.maxURLs)
+	checker := sitemap.NewChecker(loader, func() *analyze.Analyzer {
+		return newAnalyzer(cfg, client, robotsCache)
+	})
+	checker.WithPerHostConcurrency(cfg.perHostConcurrency)
+	result, err := checker.Run(context.Background(), cfg.targets[0])
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitCode(err)
+	}
+
+	if err := writeSitemap(stdout, cfg.format, result); err != nil {
+		fmt.Fprintln(stderr, "failed to write results:", err)
+		return exitUnknownError
+	}
+	return sitemapExitCode(result)
+}
+
+// sitemapExitCode reflects the error of the first failed page, or the issues
+// found when every page was analyzed.
+func sitemapExitCode(result *sitemap.Result) int {
+	for _, page := range result.Pages {
+		if page.Err != nil {
+			return exitCode(page.Err)
+		}
+	}
+	if len(result.Issues) > 0 {
+		return exitSitemapIssues
+	}
+	return exitOK
+}
+
+func parseSitemapFlags(args []string, stderr io.Writer) (config, error) {
+	var cfg config
+	fs := newFlagSet(&cfg, stderr, "Usage: html-analyzer sitemap [flags] <sitemap url>")
+	fs.IntVar(&cfg.maxURLs, "max-urls", 500, "maximum number of pages to analyze")
+	fs.IntVar(&cfg.perHostConcurrency, "per-host-concurrency", 4, "maximum number of pages analyzed at once per host")
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+	cfg.targets = fs.Args()
+	if len(cfg.targets) != 1 || !isURL(cfg.targets[0]) {
+		fs.Usage()
+		return cfg, errors.New("missing sitemap url")
+	}
+	return cfg, validateFormat(cfg.format, stderr)
+}
+
+func writeSitemap(w io.Writer, format string, result *sitemap.Result) error {
+	switch format {
+	case formatTable:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.ToUpper(strings.Join(issueColumns, "\t")))
+		for _, issue := range result.Issues {
+			fmt.Fprintln(tw, strings.Join(issueRow(issue), "\t"))
+		}
+		return tw.Flush()
+	case formatCSV:
+		cw := csv.NewWriter(w)
+		if err := cw.Write(issueColumns); err != nil {
+			return err
+		}
+		for _, issue := range result.Issues {
+			if err := cw.Write(issueRow(issue)); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(api.NewSitemapResponse(result))
+}
+
+func issueRow(issue sitemap.Issue) []string {
+	var statusCode string
+	if issue.StatusCode != 0 {
+		statusCode = strconv.Itoa(issue.StatusCode)
+	}
+	return []string{issue.URL, string(issue.Type), statusCode, issue.Target, issue.Message}
+}
@@ -60,23 +60,16 @@ func main() {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
+		defer resp.Body.Close()
 		if resp.StatusCode >= 400 {
-			var errMessage templates.ErrorMessage
-			switch resp.StatusCode {
-			case http.StatusBadRequest:
-				errMessage = templates.ErrorMessage{
-					Message: "The URL requested is not valid",
-				}
-			case http.StatusNotFound:
-				errMessage = templates.ErrorMessage{
-					Message: "The URL requested was not found",
-				}
-			default:
-				errMessage = templates.ErrorMessage{
-					Message: "An error occurred while processing the request with the status code: " + strconv.Itoa(resp.StatusCode),
-				}
+			errMessage := templates.ErrorMessage{
+				URL:     url,
+				Message: "An error occurred while processing the request with the status code: " + strconv.Itoa(resp.StatusCode),
+			}
+			var errResponse api.ErrorResponse
+			if err := json.NewDecoder(resp.Body).Decode(&errResponse); err == nil && errResponse.Message != "" {
+				errMessage.Message = errResponse.Message
 			}
-			errMessage.URL = url
 			component = templates.ErrorsTemplate(errMessage)
 			err = component.Render(context.Background(), w)
 			if err != nil {
@@ -84,9 +77,7 @@ func main() {
 			}
 			return
 		}
-		defer resp.Body.Close()
 		var details *api.DetailsResponse
-		defer resp.Body.Close()
 		body, err := io.ReadAll(resp.Body)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
@@ -106,10 +97,14 @@ func main() {
 			{Key: "H6", Count: strconv.Itoa(details.Headings.H6)},
 		}
 
+		var version string
+		if details.Version != nil {
+			version = details.Version.Name
+		}
 		d := templates.Details{
 			URL:         url,
 			Title:       details.Title,
-			HTMLVersion: details.Version.Number,
+			HTMLVersion: version,
 			Headings:    headings,
 			Links: templates.Links{
 				InternalTotal:     details.Links.Internal.Total,
@@ -0,0 +1,233 @@
+package crawl
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"sort"
+	"sync"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze"
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"github.com/danielperaltamadriz/html-analyzer/internal/hostlimit"
+	"github.com/danielperaltamadriz/html-analyzer/internal/safe"
+)
+
+const (
+	_defaultMaxDepth           = 2
+	_defaultMaxPages           = 100
+	_defaultPerHostConcurrency = 4
+)
+
+type Page struct {
+	URL     string
+	Depth   int
+	Details *models.HTMLDetails
+	Err     error
+}
+
+type Aggregate struct {
+	Pages              int
+	FailedPages        int
+	HeadingsCounter    map[models.Heading]int
+	TotalHeadings      int
+	BrokenLinks        int
+	PagesWithLoginForm int
+}
+
+type Result struct {
+	Pages     []Page
+	Aggregate Aggregate
+}
+
+type Crawler struct {
+	newAnalyzer func() *analyze.Analyzer
+
+	maxDepth           int
+	maxPages           int
+	include            []*regexp.Regexp
+	exclude            []*regexp.Regexp
+	perHostConcurrency int
+}
+
+func NewCrawler(newAnalyzer func() *analyze.Analyzer) *Crawler {
+	return &Crawler{
+		newAnalyzer:        newAnalyzer,
+		maxDepth:           _defaultMaxDepth,
+		maxPages:           _defaultMaxPages,
+		perHostConcurrency: _defaultPerHostConcurrency,
+	}
+}
+
+func (c *Crawler) WithMaxDepth(maxDepth int) {
+	c.maxDepth = maxDepth
+}
+
+func (c *Crawler) WithMaxPages(maxPages int) {
+	c.maxPages = maxPages
+}
+
+func (c *Crawler) WithInclude(patterns ...*regexp.Regexp) {
+	c.include = patterns
+}
+
+func (c *Crawler) WithExclude(patterns ...*regexp.Regexp) {
+	c.exclude = patterns
+}
+
+func (c *Crawler) WithPerHostConcurrency(concurrency int) {
+	c.perHostConcurrency = concurrency
+}
+
+func (c *Crawler) Run(ctx context.Context, startURL string) (*Result, error) {
+	start, err := url.ParseRequestURI(startURL)
+	if err != nil || start.Scheme == "" || start.Host == "" {
+		return nil, models.NewError(models.ErrTypeInvalidURL, "invalid url")
+	}
+	start.Fragment = ""
+
+	visited := map[string]bool{start.String(): true}
+	queue := []string{start.String()}
+	queued := 1
+	hosts := hostlimit.New(c.perHostConcurrency)
+
+	var result Result
+	for depth := 0; len(queue) > 0 && depth <= c.maxDepth; depth++ {
+		if ctx.Err() != nil {
+			break
+		}
+		pages := c.analyzeLevel(ctx, hosts, queue, depth)
+		queue = nil
+		for _, page := range pages {
+			result.Pages = append(result.Pages, page)
+			if page.Details == nil {
+				continue
+			}
+			if final, ok := finalURL(page.Details); ok {
+				visited[final.String()] = true
+				// A start page redirecting to another host (http to https,
+				// apex to www) moves the crawl to that host.
+				if depth == 0 {
+					start = final
+				}
+			}
+			if depth == c.maxDepth {
+				continue
+			}
+			for _, next := range c.nextURLs(start, page.Details.Links) {
+				if visited[next] || queued >= c.maxPages {
+					continue
+				}
+				visited[next] = true
+				queued++
+				queue = append(queue, next)
+			}
+		}
+	}
+	result.Aggregate = aggregate(result.Pages)
+	return &result, ctx.Err()
+}
+
+func (c *Crawler) analyzeLevel(ctx context.Context, hosts *hostlimit.Limiter, urls []string, depth int) []Page {
+	pages := make([]Page, len(urls))
+	var wg sync.WaitGroup
+	for i, pageURL := range urls {
+		wg.Add(1)
+		go func(i int, pageURL string) {
+			defer wg.Done()
+			release, err := hosts.Acquire(ctx, pageURL)
+			if err != nil {
+				pages[i] = Page{URL: pageURL, Depth: depth, Err: err}
+				return
+			}
+			defer release()
+
+			analyzer := c.newAnalyzer()
+			analyzer.WithContext(ctx)
+			details, err := safe.Run(func() (*models.HTMLDetails, error) {
+				return analyzer.RunFromURL(pageURL)
+			})
+			pages[i] = Page{URL: pageURL, Depth: depth, Details: details, Err: err}
+		}(i, pageURL)
+	}
+	wg.Wait()
+	return pages
+}
+
+func finalURL(details *models.HTMLDetails) (*url.URL, bool) {
+	if details.FinalURL == "" {
+		return nil, false
+	}
+	final, err := url.Parse(details.FinalURL)
+	if err != nil {
+		return nil, false
+	}
+	final.Fragment = ""
+	return final, true
+}
+
+func (c *Crawler) nextURLs(start *url.URL, links models.Links) []string {
+	var urls []string
+	for _, link := range links {
+		if len(link.Kinds) > 0 && link.Kinds[models.LinkKindAnchor] == 0 {
+			continue
+		}
+		u, err := url.Parse(link.URL)
+		if err != nil || u.Host != start.Host || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		u.Fragment = ""
+		next := u.String()
+		if !c.allowed(next) {
+			continue
+		}
+		urls = append(urls, next)
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+func (c *Crawler) allowed(pageURL string) bool {
+	for _, pattern := range c.exclude {
+		if pattern.MatchString(pageURL) {
+			return false
+		}
+	}
+	if len(c.include) == 0 {
+		return true
+	}
+	for _, pattern := range c.include {
+		if pattern.MatchString(pageURL) {
+			return true
+		}
+	}
+	return false
+}
+
+func aggregate(pages []Page) Aggregate {
+	agg := Aggregate{
+		Pages:           len(pages),
+		HeadingsCounter: make(map[models.Heading]int),
+	}
+	brokenLinks := make(map[string]bool)
+	for _, page := range pages {
+		if page.Details == nil {
+			agg.FailedPages++
+			continue
+		}
+		for heading, count := range page.Details.HeadingsCounter {
+			agg.HeadingsCounter[heading] += count
+			agg.TotalHeadings += count
+		}
+		for _, link := range page.Details.Links {
+			if !link.Accessible && !link.Skipped {
+				brokenLinks[link.URL] = true
+			}
+		}
+		if page.Details.HasLoginForm {
+			agg.PagesWithLoginForm++
+		}
+	}
+	agg.BrokenLinks = len(brokenLinks)
+	return agg
+}
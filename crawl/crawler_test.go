@@ -0,0 +1,220 @@
+package crawl_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze"
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"github.com/danielperaltamadriz/html-analyzer/crawl"
+	"github.com/danielperaltamadriz/html-analyzer/robots"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/html"
+)
+
+var site = map[string]string{
+	"/": `<html><body><h1>Home</h1>
+		<a href="/a">A</a><a href="/b">B</a><a href="/private/x">X</a>
+		<a href="https://external.example.com">External</a></body></html>`,
+	"/a":         `<html><body><h1>A</h1><h2>A.1</h2><a href="/c">C</a><a href="/broken">Broken</a></body></html>`,
+	"/b":         `<html><body><h1>B</h1><form><input type="password"></form><a href="/">Home</a></body></html>`,
+	"/c":         `<html><body><h1>C</h1></body></html>`,
+	"/private/x": `<html><body><h1>X</h1></body></html>`,
+}
+
+type crawlerTestSuite struct {
+	suite.Suite
+	server *httptest.Server
+}
+
+func TestCrawlerSuite(t *testing.T) {
+	suite.Run(t, new(crawlerTestSuite))
+}
+
+func (suite *crawlerTestSuite) SetupSuite() {
+	suite.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page, ok := site[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html")
+		w.Write([]byte(page)) // nolint: errcheck
+	}))
+}
+
+func (suite *crawlerTestSuite) TearDownSuite() {
+	suite.server.Close()
+}
+
+func (suite *crawlerTestSuite) TestCrawl() {
+	testCases := []struct {
+		name     string
+		setup    func(c *crawl.Crawler)
+		expected []string
+
+		expectedAggregate crawl.Aggregate
+	}{
+		{
+			name:     "crawl only the start page",
+			setup:    func(c *crawl.Crawler) { c.WithMaxDepth(0) },
+			expected: []string{"/"},
+			expectedAggregate: crawl.Aggregate{
+				Pages:           1,
+				HeadingsCounter: map[models.Heading]int{models.H1: 1},
+				TotalHeadings:   1,
+			},
+		},
+		{
+			name:     "crawl every internal page excluding private pages",
+			setup:    func(c *crawl.Crawler) { c.WithExclude(regexp.MustCompile("/private/")) },
+			expected: []string{"/", "/a", "/b", "/broken", "/c"},
+			expectedAggregate: crawl.Aggregate{
+				Pages:              5,
+				FailedPages:        1,
+				HeadingsCounter:    map[models.Heading]int{models.H1: 4, models.H2: 1},
+				TotalHeadings:      5,
+				BrokenLinks:        1,
+				PagesWithLoginForm: 1,
+			},
+		},
+		{
+			name:     "crawl with max pages",
+			setup:    func(c *crawl.Crawler) { c.WithMaxPages(2) },
+			expected: []string{"/", "/a"},
+			expectedAggregate: crawl.Aggregate{
+				Pages:           2,
+				HeadingsCounter: map[models.Heading]int{models.H1: 2, models.H2: 1},
+				TotalHeadings:   3,
+				BrokenLinks:     1,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			crawler := crawl.NewCrawler(suite.newAnalyzer)
+			tc.setup(crawler)
+			result, err := crawler.Run(context.Background(), suite.server.URL+"/")
+			suite.Require().NoError(err)
+
+			var paths []string
+			for _, page := range result.Pages {
+				paths = append(paths, strings.TrimPrefix(page.URL, suite.server.URL))
+			}
+			sort.Strings(paths)
+			suite.Equal(tc.expected, paths)
+			suite.Equal(tc.expectedAggregate, result.Aggregate)
+		})
+	}
+}
+
+func (suite *crawlerTestSuite) TestCrawlRedirectedStart() {
+	redirect := httptest.NewServer(http.RedirectHandler(suite.server.URL+"/", http.StatusMovedPermanently))
+	defer redirect.Close()
+
+	crawler := crawl.NewCrawler(suite.newAnalyzer)
+	crawler.WithExclude(regexp.MustCompile("/private/"))
+	result, err := crawler.Run(context.Background(), redirect.URL+"/")
+	suite.Require().NoError(err)
+
+	var urls []string
+	for _, page := range result.Pages {
+		urls = append(urls, page.URL)
+	}
+	suite.Equal([]string{
+		redirect.URL + "/",
+		suite.server.URL + "/a",
+		suite.server.URL + "/b",
+		suite.server.URL + "/broken",
+		suite.server.URL + "/c",
+	}, urls)
+}
+
+func (suite *crawlerTestSuite) TestCrawlSkippedLinks() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			w.Write([]byte("User-agent: *\nDisallow: /private/\n")) // nolint: errcheck
+		case "/":
+			w.Header().Set("Content-Type", "text/html")
+			w.Write([]byte(`<html><body><a href="/private/x">X</a><a href="/broken">Broken</a></body></html>`)) // nolint: errcheck
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	crawler := crawl.NewCrawler(func() *analyze.Analyzer {
+		analyzer := analyze.NewAnalyzer()
+		analyzer.WithSearchManyElements(analyzer.Links)
+		analyzer.WithRobots(robots.NewCache(http.DefaultClient, analyze.DefaultUserAgent), true)
+		return analyzer
+	})
+	crawler.WithMaxDepth(0)
+	result, err := crawler.Run(context.Background(), server.URL+"/")
+	suite.Require().NoError(err)
+	suite.Equal(1, result.Aggregate.BrokenLinks)
+}
+
+func (suite *crawlerTestSuite) TestCrawlPanickingPage() {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		switch r.URL.Path {
+		case "/":
+			w.Write([]byte(`<html><body><a href="/panic">Panic</a><a href="/ok">OK</a></body></html>`)) // nolint: errcheck
+		case "/panic":
+			w.Write([]byte(`<html><body><x-panic></x-panic></body></html>`)) // nolint: errcheck
+		default:
+			w.Write([]byte(`<html><body><h1>OK</h1></body></html>`)) // nolint: errcheck
+		}
+	}))
+	defer server.Close()
+
+	crawler := crawl.NewCrawler(func() *analyze.Analyzer {
+		analyzer := analyze.NewAnalyzer()
+		analyzer.WithSearchManyElements(analyzer.Headings, analyzer.Links, func(n *html.Node) bool {
+			if n.Type == html.ElementNode && n.Data == "x-panic" {
+				panic("unexpected page")
+			}
+			return false
+		})
+		analyzer.WithLinkVerifierFunc(func(l *models.Link) bool { return true })
+		return analyzer
+	})
+	result, err := crawler.Run(context.Background(), server.URL+"/")
+	suite.Require().NoError(err)
+	suite.Equal(3, result.Aggregate.Pages)
+	suite.Equal(1, result.Aggregate.FailedPages)
+	for _, page := range result.Pages {
+		if page.URL == server.URL+"/panic" {
+			suite.EqualError(page.Err, "failed to analyze page")
+		}
+	}
+}
+
+func (suite *crawlerTestSuite) TestCrawlInvalidURL() {
+	crawler := crawl.NewCrawler(suite.newAnalyzer)
+	result, err := crawler.Run(context.Background(), "invalid_url")
+	suite.Nil(result)
+	suite.ErrorContains(err, "invalid url")
+}
+
+func (suite *crawlerTestSuite) newAnalyzer() *analyze.Analyzer {
+	analyzer := analyze.NewAnalyzer()
+	analyzer.WithSearchSingleElements(analyzer.HasLoginForm)
+	analyzer.WithSearchManyElements(analyzer.Headings, analyzer.Links)
+	analyzer.WithLinkVerifierFunc(func(l *models.Link) bool {
+		if l.Type == models.LinkTypeExternal {
+			return true
+		}
+		_, ok := site[strings.TrimPrefix(l.URL, suite.server.URL)]
+		return ok
+	})
+	return analyzer
+}
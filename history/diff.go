@@ -0,0 +1,82 @@
+package history
+
+import (
+	"sort"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+)
+
+type Change[T comparable] struct {
+	From T
+	To   T
+}
+
+// Diff reports what changed between two analyses of a page. The changes are
+// nil when the value is the same in both.
+type Diff struct {
+	From        Record
+	To          Record
+	Title       *Change[string]
+	HTMLVersion *Change[string]
+	LoginForm   *Change[bool]
+	// HeadingDeltas holds the difference in the number of headings of every
+	// level whose count changed.
+	HeadingDeltas    map[models.Heading]int
+	NewlyBrokenLinks []string
+	FixedLinks       []string
+}
+
+func Compare(from, to Record) Diff {
+	diff := Diff{
+		From:          from,
+		To:            to,
+		HeadingDeltas: make(map[models.Heading]int),
+	}
+	before, after := from.Details, to.Details
+	if before == nil {
+		before = &models.HTMLDetails{}
+	}
+	if after == nil {
+		after = &models.HTMLDetails{}
+	}
+
+	diff.Title = change(before.Title, after.Title)
+	diff.HTMLVersion = change(versionName(before.Version), versionName(after.Version))
+	diff.LoginForm = change(before.HasLoginForm, after.HasLoginForm)
+	for _, heading := range []models.Heading{models.H1, models.H2, models.H3, models.H4, models.H5, models.H6} {
+		if delta := after.HeadingsCounter[heading] - before.HeadingsCounter[heading]; delta != 0 {
+			diff.HeadingDeltas[heading] = delta
+		}
+	}
+	for url, link := range after.Links {
+		if broken(link) && !broken(before.Links[url]) {
+			diff.NewlyBrokenLinks = append(diff.NewlyBrokenLinks, url)
+		}
+	}
+	for url, link := range before.Links {
+		if broken(link) && after.Links[url] != nil && !broken(after.Links[url]) {
+			diff.FixedLinks = append(diff.FixedLinks, url)
+		}
+	}
+	sort.Strings(diff.NewlyBrokenLinks)
+	sort.Strings(diff.FixedLinks)
+	return diff
+}
+
+func change[T comparable](from, to T) *Change[T] {
+	if from == to {
+		return nil
+	}
+	return &Change[T]{From: from, To: to}
+}
+
+func versionName(version *models.HTMLVersion) string {
+	if version == nil {
+		return ""
+	}
+	return version.Name()
+}
+
+func broken(link *models.Link) bool {
+	return link != nil && !link.Accessible && !link.Skipped
+}
@@ -0,0 +1,37 @@
+package history
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+)
+
+var ErrNotFound = errors.New("analysis not found")
+
+// Record is an analysis of a page at a point in time.
+type Record struct {
+	ID        string
+	URL       string
+	Details   *models.HTMLDetails
+	CreatedAt time.Time
+}
+
+type Store interface {
+	Save(ctx context.Context, record Record) error
+	Get(ctx context.Context, id string) (Record, error)
+	// History returns the latest records of url, most recent first. URLs are
+	// compared once normalized.
+	History(ctx context.Context, url string, limit int) ([]Record, error)
+}
+
+func NewID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
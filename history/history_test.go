@@ -0,0 +1,147 @@
+package history_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"github.com/danielperaltamadriz/html-analyzer/history"
+	"github.com/stretchr/testify/suite"
+)
+
+type historyTestSuite struct {
+	suite.Suite
+}
+
+func TestHistorySuite(t *testing.T) {
+	suite.Run(t, new(historyTestSuite))
+}
+
+func (suite *historyTestSuite) TestStores() {
+	sqliteStore, err := history.NewSQLiteStore(filepath.Join(suite.T().TempDir(), "history.db"))
+	suite.Require().NoError(err)
+	defer sqliteStore.Close()
+
+	testCases := []struct {
+		name  string
+		store history.Store
+	}{
+		{name: "memory", store: history.NewMemoryStore()},
+		{name: "sqlite", store: sqliteStore},
+	}
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			ctx := context.Background()
+			now := time.Now()
+			records := []history.Record{
+				{ID: "1", URL: "https://example.com", CreatedAt: now.Add(-2 * time.Hour), Details: &models.HTMLDetails{Title: "first"}},
+				{ID: "2", URL: "https://EXAMPLE.com/", CreatedAt: now.Add(-time.Hour), Details: &models.HTMLDetails{
+					Title:           "second",
+					HeadingsCounter: map[models.Heading]int{models.H1: 1},
+					Links: models.Links{
+						"https://example.com/a": {URL: "https://example.com/a", Count: 1, Type: models.LinkTypeInternal, Kind: models.LinkKindAnchor, StatusCode: 404},
+					},
+				}},
+				{ID: "3", URL: "https://other.com", CreatedAt: now, Details: &models.HTMLDetails{Title: "other"}},
+			}
+			for _, record := range records {
+				suite.Require().NoError(tc.store.Save(ctx, record))
+			}
+
+			record, err := tc.store.Get(ctx, "2")
+			suite.Require().NoError(err)
+			suite.Equal(records[1].Details, record.Details)
+			suite.True(records[1].CreatedAt.Equal(record.CreatedAt))
+
+			_, err = tc.store.Get(ctx, "missing")
+			suite.ErrorIs(err, history.ErrNotFound)
+
+			found, err := tc.store.History(ctx, "https://example.com/", 10)
+			suite.Require().NoError(err)
+			suite.Equal([]string{"2", "1"}, recordIDs(found))
+
+			found, err = tc.store.History(ctx, "https://example.com", 1)
+			suite.Require().NoError(err)
+			suite.Equal([]string{"2"}, recordIDs(found))
+		})
+	}
+}
+
+func (suite *historyTestSuite) TestMemoryStoreMaxRecords() {
+	ctx := context.Background()
+	now := time.Now()
+	store := history.NewMemoryStore()
+	store.WithMaxRecords(2, 3)
+	records := []history.Record{
+		{ID: "1", URL: "https://example.com", CreatedAt: now},
+		{ID: "2", URL: "https://example.com", CreatedAt: now.Add(time.Second)},
+		{ID: "3", URL: "https://example.com", CreatedAt: now.Add(2 * time.Second)},
+		{ID: "4", URL: "https://other.com", CreatedAt: now.Add(3 * time.Second)},
+		{ID: "5", URL: "https://another.com", CreatedAt: now.Add(4 * time.Second)},
+	}
+	for _, record := range records {
+		suite.Require().NoError(store.Save(ctx, record))
+	}
+
+	found, err := store.History(ctx, "https://example.com", 10)
+	suite.Require().NoError(err)
+	suite.Equal([]string{"3"}, recordIDs(found))
+	for _, id := range []string{"1", "2"} {
+		_, err = store.Get(ctx, id)
+		suite.ErrorIs(err, history.ErrNotFound)
+	}
+	for _, id := range []string{"3", "4", "5"} {
+		_, err = store.Get(ctx, id)
+		suite.NoError(err)
+	}
+}
+
+func (suite *historyTestSuite) TestCompare() {
+	from := history.Record{ID: "1", Details: &models.HTMLDetails{
+		Title:           "Old title",
+		Version:         &models.HTMLVersion{Number: models.HTMLVersion401, Strict: true, Flavor: models.HTMLFlavorStrict},
+		HeadingsCounter: map[models.Heading]int{models.H1: 1, models.H2: 3},
+		HasLoginForm:    true,
+		Links: models.Links{
+			"https://example.com/fixed": {URL: "https://example.com/fixed"},
+			"https://example.com/ok":    {URL: "https://example.com/ok", Accessible: true},
+			"https://example.com/gone":  {URL: "https://example.com/gone"},
+		},
+	}}
+	to := history.Record{ID: "2", Details: &models.HTMLDetails{
+		Title:           "New title",
+		Version:         &models.HTMLVersion{Number: models.HTMLVersion5},
+		HeadingsCounter: map[models.Heading]int{models.H1: 1, models.H2: 1, models.H3: 2},
+		Links: models.Links{
+			"https://example.com/fixed":   {URL: "https://example.com/fixed", Accessible: true},
+			"https://example.com/ok":      {URL: "https://example.com/ok"},
+			"https://example.com/new":     {URL: "https://example.com/new"},
+			"https://example.com/skipped": {URL: "https://example.com/skipped", Skipped: true},
+		},
+	}}
+
+	diff := history.Compare(from, to)
+	suite.Equal(&history.Change[string]{From: "Old title", To: "New title"}, diff.Title)
+	suite.Equal(&history.Change[string]{From: "HTML 4.01 Strict", To: "HTML 5"}, diff.HTMLVersion)
+	suite.Equal(&history.Change[bool]{From: true, To: false}, diff.LoginForm)
+	suite.Equal(map[models.Heading]int{models.H2: -2, models.H3: 2}, diff.HeadingDeltas)
+	suite.Equal([]string{"https://example.com/new", "https://example.com/ok"}, diff.NewlyBrokenLinks)
+	suite.Equal([]string{"https://example.com/fixed"}, diff.FixedLinks)
+
+	unchanged := history.Compare(from, from)
+	suite.Nil(unchanged.Title)
+	suite.Nil(unchanged.HTMLVersion)
+	suite.Nil(unchanged.LoginForm)
+	suite.Empty(unchanged.HeadingDeltas)
+	suite.Empty(unchanged.NewlyBrokenLinks)
+}
+
+func recordIDs(records []history.Record) []string {
+	ids := make([]string, 0, len(records))
+	for _, record := range records {
+		ids = append(ids, record.ID)
+	}
+	return ids
+}
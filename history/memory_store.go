@@ -0,0 +1,107 @@
+package history
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/danielperaltamadriz/html-analyzer/cache"
+)
+
+const (
+	_defaultMaxRecordsPerURL = 100
+	_defaultMaxRecords       = 10000
+)
+
+// MemoryStore keeps the latest records of every URL, evicting the oldest
+// saved records once a URL or the whole store is full.
+type MemoryStore struct {
+	maxRecordsPerURL int
+	maxRecords       int
+
+	mu      sync.RWMutex
+	records map[string]Record
+	byURL   map[string][]string
+	// order holds the ids in the order they were saved.
+	order []string
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		maxRecordsPerURL: _defaultMaxRecordsPerURL,
+		maxRecords:       _defaultMaxRecords,
+		records:          make(map[string]Record),
+		byURL:            make(map[string][]string),
+	}
+}
+
+func (s *MemoryStore) WithMaxRecords(perURL, total int) {
+	s.maxRecordsPerURL = perURL
+	s.maxRecords = total
+}
+
+func (s *MemoryStore) Save(_ context.Context, record Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.records[record.ID]; ok {
+		s.records[record.ID] = record
+		return nil
+	}
+	key := cache.NormalizeURL(record.URL)
+	s.records[record.ID] = record
+	s.byURL[key] = append(s.byURL[key], record.ID)
+	s.order = append(s.order, record.ID)
+	if len(s.byURL[key]) > s.maxRecordsPerURL {
+		s.remove(s.byURL[key][0])
+	}
+	for len(s.records) > s.maxRecords {
+		s.remove(s.order[0])
+	}
+	return nil
+}
+
+func (s *MemoryStore) remove(id string) {
+	key := cache.NormalizeURL(s.records[id].URL)
+	delete(s.records, id)
+	s.byURL[key] = without(s.byURL[key], id)
+	if len(s.byURL[key]) == 0 {
+		delete(s.byURL, key)
+	}
+	s.order = without(s.order, id)
+}
+
+func without(ids []string, id string) []string {
+	for i := range ids {
+		if ids[i] == id {
+			return append(ids[:i:i], ids[i+1:]...)
+		}
+	}
+	return ids
+}
+
+func (s *MemoryStore) Get(_ context.Context, id string) (Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, ok := s.records[id]
+	if !ok {
+		return Record{}, ErrNotFound
+	}
+	return record, nil
+}
+
+func (s *MemoryStore) History(_ context.Context, url string, limit int) ([]Record, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	ids := s.byURL[cache.NormalizeURL(url)]
+	records := make([]Record, 0, len(ids))
+	for _, id := range ids {
+		records = append(records, s.records[id])
+	}
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].CreatedAt.After(records[j].CreatedAt)
+	})
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+	return records, nil
+}
@@ -0,0 +1,116 @@
+package history
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"github.com/danielperaltamadriz/html-analyzer/cache"
+	_ "modernc.org/sqlite"
+)
+
+const _schema = `
+CREATE TABLE IF NOT EXISTS analyses (
+	id         TEXT PRIMARY KEY,
+	url        TEXT NOT NULL,
+	url_key    TEXT NOT NULL,
+	details    TEXT NOT NULL,
+	created_at INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS analyses_url_key_created_at ON analyses (url_key, created_at DESC);
+`
+
+// SQLiteStore keeps the records in a SQLite database, with the details
+// encoded as JSON.
+type SQLiteStore struct {
+	db *sql.DB
+}
+
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("sql.Open: %w", err)
+	}
+	// SQLite allows a single writer, so a single connection avoids busy
+	// errors between concurrent analyses.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(_schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create schema: %w", err)
+	}
+	return &SQLiteStore{db: db}, nil
+}
+
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteStore) Save(ctx context.Context, record Record) error {
+	details, err := json.Marshal(record.Details)
+	if err != nil {
+		return fmt.Errorf("failed to encode details: %w", err)
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT OR REPLACE INTO analyses (id, url, url_key, details, created_at) VALUES (?, ?, ?, ?, ?)`,
+		record.ID, record.URL, cache.NormalizeURL(record.URL), string(details), record.CreatedAt.UnixNano())
+	if err != nil {
+		return fmt.Errorf("failed to insert analysis: %w", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStore) Get(ctx context.Context, id string) (Record, error) {
+	row := s.db.QueryRowContext(ctx, `SELECT id, url, details, created_at FROM analyses WHERE id = ?`, id)
+	record, err := scanRecord(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Record{}, ErrNotFound
+	}
+	return record, err
+}
+
+func (s *SQLiteStore) History(ctx context.Context, url string, limit int) ([]Record, error) {
+	if limit <= 0 {
+		limit = -1
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, url, details, created_at FROM analyses WHERE url_key = ? ORDER BY created_at DESC LIMIT ?`,
+		cache.NormalizeURL(url), limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query history: %w", err)
+	}
+	defer rows.Close()
+	var records []Record
+	for rows.Next() {
+		record, err := scanRecord(rows)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, rows.Err()
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRecord(row scanner) (Record, error) {
+	var (
+		record    Record
+		details   string
+		createdAt int64
+	)
+	if err := row.Scan(&record.ID, &record.URL, &details, &createdAt); err != nil {
+		return Record{}, err
+	}
+	record.Details = &models.HTMLDetails{}
+	if err := json.Unmarshal([]byte(details), record.Details); err != nil {
+		return Record{}, fmt.Errorf("failed to decode details: %w", err)
+	}
+	record.CreatedAt = time.Unix(0, createdAt)
+	return record, nil
+}
@@ -0,0 +1,45 @@
+package hostlimit
+
+import (
+	"context"
+	"net/url"
+	"sync"
+)
+
+type Limiter struct {
+	mu          sync.Mutex
+	concurrency int
+	semaphores  map[string]chan struct{}
+}
+
+func New(concurrency int) *Limiter {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+	return &Limiter{
+		concurrency: concurrency,
+		semaphores:  make(map[string]chan struct{}),
+	}
+}
+
+func (h *Limiter) Acquire(ctx context.Context, rawURL string) (func(), error) {
+	var host string
+	if u, err := url.Parse(rawURL); err == nil {
+		host = u.Host
+	}
+
+	h.mu.Lock()
+	semaphore, ok := h.semaphores[host]
+	if !ok {
+		semaphore = make(chan struct{}, h.concurrency)
+		h.semaphores[host] = semaphore
+	}
+	h.mu.Unlock()
+
+	select {
+	case semaphore <- struct{}{}:
+		return func() { <-semaphore }, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
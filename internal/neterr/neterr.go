@@ -0,0 +1,14 @@
+package neterr
+
+import (
+	"context"
+	"errors"
+	"net"
+)
+
+// IsTimeout reports whether err comes from an expired deadline or a network
+// timeout.
+func IsTimeout(err error) bool {
+	var netErr net.Error
+	return errors.Is(err, context.DeadlineExceeded) || errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,22 @@
+package safe
+
+import (
+	"fmt"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+)
+
+// ErrRecovered is returned by Run when run panics.
+var ErrRecovered = models.NewError(models.ErrTypeUnknown, "failed to analyze page")
+
+// Run calls run and returns its panic as an error, so a page breaking the
+// analyzer fails on its own instead of taking down the process.
+func Run[T any](run func() (T, error)) (result T, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("recovered panic, error: %v \n", r)
+			err = ErrRecovered
+		}
+	}()
+	return run()
+}
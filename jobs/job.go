@@ -0,0 +1,52 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+)
+
+type Status string
+
+const (
+	StatusPending   Status = "pending"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+	StatusCanceled  Status = "canceled"
+)
+
+var (
+	ErrNotFound  = errors.New("job not found")
+	ErrFinished  = errors.New("job already finished")
+	ErrQueueFull = errors.New("job queue is full")
+	ErrClosed    = errors.New("job manager is closed")
+)
+
+type Progress struct {
+	LinksVerified int
+	LinksTotal    int
+}
+
+type Job struct {
+	ID        string
+	URL       string
+	Status    Status
+	Progress  Progress
+	Details   *models.HTMLDetails
+	Err       error
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+func (j Job) Finished() bool {
+	return j.Status == StatusCompleted || j.Status == StatusFailed || j.Status == StatusCanceled
+}
+
+type Store interface {
+	Create(ctx context.Context, job Job) error
+	Get(ctx context.Context, id string) (Job, error)
+	Update(ctx context.Context, id string, update func(job *Job)) error
+}
@@ -0,0 +1,252 @@
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"github.com/danielperaltamadriz/html-analyzer/internal/safe"
+)
+
+type RunFunc func(ctx context.Context, url string, progress func(verified, total int)) (*models.HTMLDetails, error)
+
+const (
+	_defaultWorkers   = 4
+	_defaultQueueSize = 100
+)
+
+type task struct {
+	ctx     context.Context
+	id      string
+	url     string
+	run     RunFunc
+	private bool
+}
+
+// Manager runs the jobs on a fixed number of workers. Submit fails with
+// ErrQueueFull when more jobs are pending than the queue can hold.
+type Manager struct {
+	store       Store
+	onCompleted func(job Job)
+	workers     int
+	queueSize   int
+
+	start   sync.Once
+	mu      sync.Mutex
+	queue   chan task
+	closed  bool
+	cancels map[string]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+func NewManager(store Store) *Manager {
+	return &Manager{
+		store:     store,
+		workers:   _defaultWorkers,
+		queueSize: _defaultQueueSize,
+		cancels:   make(map[string]context.CancelFunc),
+	}
+}
+
+// WithWorkers and WithQueueSize must be called before the first Submit.
+func (m *Manager) WithWorkers(workers int) {
+	m.workers = workers
+}
+
+func (m *Manager) WithQueueSize(queueSize int) {
+	m.queueSize = queueSize
+}
+
+// WithCompletedFunc calls onCompleted with every job that completes
+// successfully, except the private ones.
+func (m *Manager) WithCompletedFunc(onCompleted func(job Job)) {
+	m.onCompleted = onCompleted
+}
+
+func (m *Manager) Submit(ctx context.Context, url string, run RunFunc) (Job, error) {
+	return m.submit(ctx, url, run, false)
+}
+
+// SubmitPrivate submits a job whose result is not passed to the completed
+// func, like an analysis sent with the caller's credentials.
+func (m *Manager) SubmitPrivate(ctx context.Context, url string, run RunFunc) (Job, error) {
+	return m.submit(ctx, url, run, true)
+}
+
+func (m *Manager) submit(ctx context.Context, url string, run RunFunc, private bool) (Job, error) {
+	m.start.Do(m.startWorkers)
+	id, err := newID()
+	if err != nil {
+		return Job{}, fmt.Errorf("newID: %w", err)
+	}
+	now := time.Now()
+	job := Job{
+		ID:        id,
+		URL:       url,
+		Status:    StatusPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	// Only Submit sends to the queue and it holds the lock, so the queue
+	// cannot fill up between the check and the send.
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return Job{}, ErrClosed
+	}
+	if len(m.queue) == cap(m.queue) {
+		return Job{}, ErrQueueFull
+	}
+	if err := m.store.Create(ctx, job); err != nil {
+		return Job{}, fmt.Errorf("store.Create: %w", err)
+	}
+	jobCtx, cancel := context.WithCancel(context.Background())
+	m.cancels[id] = cancel
+	m.queue <- task{ctx: jobCtx, id: id, url: url, run: run, private: private}
+	return job, nil
+}
+
+func (m *Manager) startWorkers() {
+	workers := m.workers
+	if workers < 1 {
+		workers = 1
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return
+	}
+	m.queue = make(chan task, m.queueSize)
+	for i := 0; i < workers; i++ {
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			for t := range m.queue {
+				m.execute(t)
+			}
+		}()
+	}
+}
+
+func (m *Manager) Get(ctx context.Context, id string) (Job, error) {
+	return m.store.Get(ctx, id)
+}
+
+func (m *Manager) Cancel(ctx context.Context, id string) (Job, error) {
+	job, err := m.store.Get(ctx, id)
+	if err != nil {
+		return Job{}, err
+	}
+	if job.Finished() {
+		return job, ErrFinished
+	}
+
+	m.mu.Lock()
+	cancel, ok := m.cancels[id]
+	m.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	err = m.store.Update(ctx, id, func(job *Job) {
+		if !job.Finished() {
+			job.Status = StatusCanceled
+		}
+	})
+	if err != nil {
+		return Job{}, err
+	}
+	return m.store.Get(ctx, id)
+}
+
+// Close cancels the running and pending jobs and waits for the workers.
+func (m *Manager) Close() {
+	m.mu.Lock()
+	for _, cancel := range m.cancels {
+		cancel()
+	}
+	if !m.closed && m.queue != nil {
+		close(m.queue)
+	}
+	m.closed = true
+	m.mu.Unlock()
+	m.wg.Wait()
+}
+
+func (m *Manager) execute(t task) {
+	ctx, id := t.ctx, t.id
+	defer func() {
+		m.mu.Lock()
+		if cancel, ok := m.cancels[id]; ok {
+			cancel()
+			delete(m.cancels, id)
+		}
+		m.mu.Unlock()
+	}()
+
+	if ctx.Err() != nil {
+		m.update(id, func(job *Job) {
+			job.Status = StatusCanceled
+		})
+		return
+	}
+	m.update(id, func(job *Job) {
+		if job.Status == StatusPending {
+			job.Status = StatusRunning
+		}
+	})
+	details, err := safe.Run(func() (*models.HTMLDetails, error) {
+		return t.run(ctx, t.url, func(verified, total int) {
+			m.update(id, func(job *Job) {
+				job.Progress.LinksTotal = total
+				if verified > job.Progress.LinksVerified {
+					job.Progress.LinksVerified = verified
+				}
+			})
+		})
+	})
+	m.update(id, func(job *Job) {
+		switch {
+		case job.Status == StatusCanceled:
+		case errors.Is(ctx.Err(), context.Canceled):
+			job.Status = StatusCanceled
+		case err != nil:
+			job.Status = StatusFailed
+			job.Err = err
+		default:
+			job.Status = StatusCompleted
+			job.Details = details
+		}
+	})
+	if m.onCompleted == nil || t.private {
+		return
+	}
+	job, err := m.store.Get(context.Background(), id)
+	if err != nil {
+		fmt.Printf("store.Get, job: %s, error: %s \n", id, err.Error())
+		return
+	}
+	if job.Status == StatusCompleted {
+		m.onCompleted(job)
+	}
+}
+
+func (m *Manager) update(id string, update func(job *Job)) {
+	err := m.store.Update(context.Background(), id, update)
+	if err != nil {
+		fmt.Printf("store.Update, job: %s, error: %s \n", id, err.Error())
+	}
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,244 @@
+package jobs_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"github.com/danielperaltamadriz/html-analyzer/jobs"
+	"github.com/stretchr/testify/suite"
+)
+
+type managerTestSuite struct {
+	suite.Suite
+}
+
+func TestManagerSuite(t *testing.T) {
+	suite.Run(t, new(managerTestSuite))
+}
+
+func (suite *managerTestSuite) TestCompletedJob() {
+	run := func(ctx context.Context, url string, progress func(verified, total int)) (*models.HTMLDetails, error) {
+		progress(1, 2)
+		progress(2, 2)
+		return &models.HTMLDetails{Title: url}, nil
+	}
+	manager := jobs.NewManager(jobs.NewMemoryStore())
+	defer manager.Close()
+
+	job, err := manager.Submit(context.Background(), "https://example.com", run)
+	suite.Require().NoError(err)
+	suite.Equal(jobs.StatusPending, job.Status)
+
+	job = suite.waitFinished(manager, job.ID)
+	suite.Equal(jobs.StatusCompleted, job.Status)
+	suite.Equal(jobs.Progress{LinksVerified: 2, LinksTotal: 2}, job.Progress)
+	suite.Equal(&models.HTMLDetails{Title: "https://example.com"}, job.Details)
+}
+
+func (suite *managerTestSuite) TestCompletedFunc() {
+	run := func(ctx context.Context, url string, progress func(verified, total int)) (*models.HTMLDetails, error) {
+		if url == "https://example.com/fail" {
+			return nil, errors.New("failed")
+		}
+		return &models.HTMLDetails{Title: url}, nil
+	}
+	completed := make(chan jobs.Job, 2)
+	manager := jobs.NewManager(jobs.NewMemoryStore())
+	manager.WithCompletedFunc(func(job jobs.Job) { completed <- job })
+
+	failed, err := manager.Submit(context.Background(), "https://example.com/fail", run)
+	suite.Require().NoError(err)
+	job, err := manager.Submit(context.Background(), "https://example.com", run)
+	suite.Require().NoError(err)
+	suite.waitFinished(manager, failed.ID)
+	suite.waitFinished(manager, job.ID)
+	manager.Close()
+	close(completed)
+
+	var ids []string
+	for completedJob := range completed {
+		ids = append(ids, completedJob.ID)
+		suite.Equal(&models.HTMLDetails{Title: "https://example.com"}, completedJob.Details)
+	}
+	suite.Equal([]string{job.ID}, ids)
+}
+
+func (suite *managerTestSuite) TestPrivateJob() {
+	run := func(ctx context.Context, url string, progress func(verified, total int)) (*models.HTMLDetails, error) {
+		return &models.HTMLDetails{Title: url}, nil
+	}
+	completed := make(chan jobs.Job, 2)
+	manager := jobs.NewManager(jobs.NewMemoryStore())
+	manager.WithCompletedFunc(func(job jobs.Job) { completed <- job })
+
+	private, err := manager.SubmitPrivate(context.Background(), "https://example.com/private", run)
+	suite.Require().NoError(err)
+	job, err := manager.Submit(context.Background(), "https://example.com", run)
+	suite.Require().NoError(err)
+	suite.Equal(jobs.StatusCompleted, suite.waitFinished(manager, private.ID).Status)
+	suite.waitFinished(manager, job.ID)
+	manager.Close()
+	close(completed)
+
+	var ids []string
+	for completedJob := range completed {
+		ids = append(ids, completedJob.ID)
+	}
+	suite.Equal([]string{job.ID}, ids)
+}
+
+func (suite *managerTestSuite) TestFailedJob() {
+	run := func(ctx context.Context, url string, progress func(verified, total int)) (*models.HTMLDetails, error) {
+		return nil, errors.New("failed")
+	}
+	manager := jobs.NewManager(jobs.NewMemoryStore())
+	defer manager.Close()
+
+	job, err := manager.Submit(context.Background(), "https://example.com", run)
+	suite.Require().NoError(err)
+
+	job = suite.waitFinished(manager, job.ID)
+	suite.Equal(jobs.StatusFailed, job.Status)
+	suite.EqualError(job.Err, "failed")
+}
+
+func (suite *managerTestSuite) TestPanickingJob() {
+	run := func(ctx context.Context, url string, progress func(verified, total int)) (*models.HTMLDetails, error) {
+		panic("boom")
+	}
+	manager := jobs.NewManager(jobs.NewMemoryStore())
+	manager.WithWorkers(1)
+	defer manager.Close()
+
+	job, err := manager.Submit(context.Background(), "https://example.com", run)
+	suite.Require().NoError(err)
+	job = suite.waitFinished(manager, job.ID)
+	suite.Equal(jobs.StatusFailed, job.Status)
+	suite.EqualError(job.Err, "failed to analyze page")
+
+	// The worker keeps running the next jobs.
+	job, err = manager.Submit(context.Background(), "https://example.com", func(ctx context.Context, url string, progress func(verified, total int)) (*models.HTMLDetails, error) {
+		return &models.HTMLDetails{Title: url}, nil
+	})
+	suite.Require().NoError(err)
+	job = suite.waitFinished(manager, job.ID)
+	suite.Equal(jobs.StatusCompleted, job.Status)
+}
+
+func (suite *managerTestSuite) TestCancelJob() {
+	started := make(chan struct{})
+	run := func(ctx context.Context, url string, progress func(verified, total int)) (*models.HTMLDetails, error) {
+		close(started)
+		<-ctx.Done()
+		return nil, ctx.Err()
+	}
+	manager := jobs.NewManager(jobs.NewMemoryStore())
+	defer manager.Close()
+
+	job, err := manager.Submit(context.Background(), "https://example.com", run)
+	suite.Require().NoError(err)
+	<-started
+
+	job, err = manager.Cancel(context.Background(), job.ID)
+	suite.Require().NoError(err)
+	suite.Equal(jobs.StatusCanceled, job.Status)
+
+	job = suite.waitFinished(manager, job.ID)
+	suite.Equal(jobs.StatusCanceled, job.Status)
+
+	_, err = manager.Cancel(context.Background(), job.ID)
+	suite.ErrorIs(err, jobs.ErrFinished)
+}
+
+func (suite *managerTestSuite) TestQueueFull() {
+	started := make(chan struct{}, 1)
+	release := make(chan struct{})
+	run := func(ctx context.Context, url string, progress func(verified, total int)) (*models.HTMLDetails, error) {
+		started <- struct{}{}
+		<-release
+		return &models.HTMLDetails{Title: url}, nil
+	}
+	manager := jobs.NewManager(jobs.NewMemoryStore())
+	manager.WithWorkers(1)
+	manager.WithQueueSize(1)
+
+	running, err := manager.Submit(context.Background(), "https://example.com/running", run)
+	suite.Require().NoError(err)
+	<-started
+	pending, err := manager.Submit(context.Background(), "https://example.com/pending", run)
+	suite.Require().NoError(err)
+	_, err = manager.Submit(context.Background(), "https://example.com/rejected", run)
+	suite.ErrorIs(err, jobs.ErrQueueFull)
+
+	close(release)
+	suite.Equal(jobs.StatusCompleted, suite.waitFinished(manager, running.ID).Status)
+	suite.Equal(jobs.StatusCompleted, suite.waitFinished(manager, pending.ID).Status)
+	manager.Close()
+
+	_, err = manager.Submit(context.Background(), "https://example.com/closed", run)
+	suite.ErrorIs(err, jobs.ErrClosed)
+}
+
+func (suite *managerTestSuite) TestCancelPendingJob() {
+	release := make(chan struct{})
+	run := func(ctx context.Context, url string, progress func(verified, total int)) (*models.HTMLDetails, error) {
+		<-release
+		return &models.HTMLDetails{Title: url}, nil
+	}
+	manager := jobs.NewManager(jobs.NewMemoryStore())
+	manager.WithWorkers(1)
+	defer manager.Close()
+
+	running, err := manager.Submit(context.Background(), "https://example.com/running", run)
+	suite.Require().NoError(err)
+	pending, err := manager.Submit(context.Background(), "https://example.com/pending", run)
+	suite.Require().NoError(err)
+	_, err = manager.Cancel(context.Background(), pending.ID)
+	suite.Require().NoError(err)
+
+	close(release)
+	suite.Equal(jobs.StatusCompleted, suite.waitFinished(manager, running.ID).Status)
+	job := suite.waitFinished(manager, pending.ID)
+	suite.Equal(jobs.StatusCanceled, job.Status)
+	suite.Nil(job.Details)
+}
+
+func (suite *managerTestSuite) TestRetention() {
+	run := func(ctx context.Context, url string, progress func(verified, total int)) (*models.HTMLDetails, error) {
+		return &models.HTMLDetails{Title: url}, nil
+	}
+	store := jobs.NewMemoryStore()
+	store.WithRetention(50 * time.Millisecond)
+	manager := jobs.NewManager(store)
+	defer manager.Close()
+
+	job, err := manager.Submit(context.Background(), "https://example.com", run)
+	suite.Require().NoError(err)
+	suite.waitFinished(manager, job.ID)
+	suite.Eventually(func() bool {
+		_, err := manager.Get(context.Background(), job.ID)
+		return errors.Is(err, jobs.ErrNotFound)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (suite *managerTestSuite) TestJobNotFound() {
+	manager := jobs.NewManager(jobs.NewMemoryStore())
+	_, err := manager.Get(context.Background(), "unknown")
+	suite.ErrorIs(err, jobs.ErrNotFound)
+	_, err = manager.Cancel(context.Background(), "unknown")
+	suite.ErrorIs(err, jobs.ErrNotFound)
+}
+
+func (suite *managerTestSuite) waitFinished(manager *jobs.Manager, id string) jobs.Job {
+	var job jobs.Job
+	suite.Eventually(func() bool {
+		var err error
+		job, err = manager.Get(context.Background(), id)
+		suite.Require().NoError(err)
+		return job.Finished()
+	}, time.Second, 10*time.Millisecond)
+	return job
+}
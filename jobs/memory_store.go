@@ -0,0 +1,81 @@
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const (
+	_defaultRetention = time.Hour
+	_sweepInterval    = time.Minute
+)
+
+// MemoryStore keeps the jobs in memory. Finished jobs are deleted once they
+// have not been updated for the retention period.
+type MemoryStore struct {
+	mu        sync.RWMutex
+	jobs      map[string]Job
+	retention time.Duration
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		jobs:      make(map[string]Job),
+		retention: _defaultRetention,
+	}
+}
+
+func (s *MemoryStore) WithRetention(retention time.Duration) {
+	s.retention = retention
+}
+
+func (s *MemoryStore) Create(_ context.Context, job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sweep(time.Now())
+	s.jobs[job.ID] = job
+	return nil
+}
+
+func (s *MemoryStore) Get(_ context.Context, id string) (Job, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	job, ok := s.jobs[id]
+	if !ok || s.expired(job, time.Now()) {
+		return Job{}, ErrNotFound
+	}
+	return job, nil
+}
+
+func (s *MemoryStore) Update(_ context.Context, id string, update func(job *Job)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return ErrNotFound
+	}
+	update(&job)
+	job.UpdatedAt = time.Now()
+	s.jobs[id] = job
+	return nil
+}
+
+func (s *MemoryStore) expired(job Job, now time.Time) bool {
+	return job.Finished() && now.Sub(job.UpdatedAt) > s.retention
+}
+
+// sweep deletes the expired jobs, at most once per sweep interval. It must be
+// called with the lock held.
+func (s *MemoryStore) sweep(now time.Time) {
+	if now.Sub(s.lastSweep) < _sweepInterval {
+		return
+	}
+	s.lastSweep = now
+	for id, job := range s.jobs {
+		if s.expired(job, now) {
+			delete(s.jobs, id)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package netguard
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var ErrBlockedAddress = errors.New("address not allowed")
+
+var _blockedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("2002::/16"),
+}
+
+// Guard blocks the connections to loopback, private, link-local and other
+// non-public addresses. The check runs on the resolved address right before
+// dialing, so a hostname that resolves to a public address and later to a
+// private one (DNS rebinding) is blocked too.
+type Guard struct {
+	allowlist []netip.Prefix
+}
+
+func New(allowlist ...netip.Prefix) *Guard {
+	return &Guard{allowlist: allowlist}
+}
+
+// ParsePrefixes parses a list of CIDRs or single addresses, like
+// "10.0.0.0/8" or "127.0.0.1".
+func ParsePrefixes(values []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		if !strings.Contains(value, "/") {
+			addr, err := netip.ParseAddr(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid address %q: %w", value, err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q: %w", value, err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+func (g *Guard) Allowed(addr netip.Addr) bool {
+	addr = addr.Unmap().WithZone("")
+	for _, prefix := range g.allowlist {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return isPublic(addr)
+}
+
+// Control is meant to be used as the Control function of a net.Dialer.
+func (g *Guard) Control(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+	}
+	if !g.Allowed(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// Client returns a copy of client that dials through the guard. Only
+// *http.Transport can be guarded, any other transport is replaced by a copy
+// of http.DefaultTransport. The proxy of the transport is dropped, as the
+// guard would only check the address of the proxy and not the target.
+func (g *Guard) Client(client *http.Client) *http.Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	transport, ok := client.Transport.(*http.Transport)
+	if !ok {
+		transport = http.DefaultTransport.(*http.Transport)
+	}
+	transport = transport.Clone()
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   g.Control,
+	}
+	transport.DialContext = dialer.DialContext
+	transport.DialTLSContext = nil
+	transport.Proxy = nil
+
+	guarded := *client
+	guarded.Transport = transport
+	return &guarded
+}
+
+func isPublic(addr netip.Addr) bool {
+	if !addr.IsValid() || addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast() {
+		return false
+	}
+	for _, prefix := range _blockedPrefixes {
+		if prefix.Contains(addr) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,108 @@
+package netguard_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"sync/atomic"
+	"testing"
+
+	"github.com/danielperaltamadriz/html-analyzer/netguard"
+	"github.com/stretchr/testify/suite"
+)
+
+type netguardTestSuite struct {
+	suite.Suite
+}
+
+func TestNetguardSuite(t *testing.T) {
+	suite.Run(t, new(netguardTestSuite))
+}
+
+func (suite *netguardTestSuite) TestAllowed() {
+	allowlist, err := netguard.ParsePrefixes([]string{"10.1.0.0/16", "192.168.1.10"})
+	suite.Require().NoError(err)
+	guard := netguard.New(allowlist...)
+
+	testCases := []struct {
+		addr    string
+		allowed bool
+	}{
+		{addr: "93.184.216.34", allowed: true},
+		{addr: "2606:4700::1111", allowed: true},
+		{addr: "127.0.0.1", allowed: false},
+		{addr: "::1", allowed: false},
+		{addr: "169.254.169.254", allowed: false},
+		{addr: "fe80::1", allowed: false},
+		{addr: "10.0.0.1", allowed: false},
+		{addr: "172.16.5.4", allowed: false},
+		{addr: "192.168.1.11", allowed: false},
+		{addr: "fd00::1", allowed: false},
+		{addr: "0.0.0.0", allowed: false},
+		{addr: "100.64.0.1", allowed: false},
+		{addr: "::ffff:127.0.0.1", allowed: false},
+		{addr: "224.0.0.1", allowed: false},
+		{addr: "10.1.2.3", allowed: true},
+		{addr: "192.168.1.10", allowed: true},
+	}
+
+	for _, tc := range testCases {
+		suite.Run(tc.addr, func() {
+			suite.Equal(tc.allowed, guard.Allowed(netip.MustParseAddr(tc.addr)))
+		})
+	}
+}
+
+func (suite *netguardTestSuite) TestParsePrefixesInvalid() {
+	_, err := netguard.ParsePrefixes([]string{"10.0.0.0/33"})
+	suite.Error(err)
+	_, err = netguard.ParsePrefixes([]string{"localhost"})
+	suite.Error(err)
+}
+
+func (suite *netguardTestSuite) TestClient() {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer fakeServer.Close()
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, fakeServer.URL, nil)
+	suite.Require().NoError(err)
+
+	_, err = netguard.New().Client(nil).Do(req)
+	suite.ErrorIs(err, netguard.ErrBlockedAddress)
+
+	resp, err := netguard.New(netip.MustParsePrefix("127.0.0.0/8")).Client(nil).Do(req)
+	suite.Require().NoError(err)
+	resp.Body.Close()
+	suite.Equal(http.StatusOK, resp.StatusCode)
+}
+
+func (suite *netguardTestSuite) TestClientWithProxy() {
+	var proxied atomic.Int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied.Add(1)
+	}))
+	defer proxy.Close()
+	proxyURL, err := url.Parse(proxy.URL)
+	suite.Require().NoError(err)
+	suite.T().Setenv("HTTP_PROXY", proxy.URL)
+
+	testCases := []struct {
+		name   string
+		client *http.Client
+	}{
+		{name: "proxy from the environment", client: nil},
+		{name: "proxy of the transport", client: &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}},
+	}
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, "http://169.254.169.254/latest/meta-data/", nil)
+			suite.Require().NoError(err)
+
+			_, err = netguard.New(netip.MustParsePrefix("127.0.0.0/8")).Client(tc.client).Do(req)
+			suite.ErrorIs(err, netguard.ErrBlockedAddress)
+			suite.Equal(int32(0), proxied.Load())
+		})
+	}
+}
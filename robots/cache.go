@@ -0,0 +1,200 @@
+package robots
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+const (
+	_defaultTTL          = 24 * time.Hour
+	_defaultFetchTimeout = 5 * time.Second
+	_maxRobotsBytes      = 500 << 10
+	_defaultMaxEntries   = 10000
+)
+
+type entry struct {
+	mu        sync.Mutex
+	rules     *Rules
+	fetchedAt time.Time
+	next      time.Time
+}
+
+// Cache fetches and caches the robots.txt of every host, and spaces out the
+// requests to a host according to its crawl delay. The rules are matched
+// against the user agent of each call, userAgent being the one sending the
+// robots.txt requests and the fallback of the calls without one.
+type Cache struct {
+	client     *http.Client
+	userAgent  string
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+func NewCache(client *http.Client, userAgent string) *Cache {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Cache{
+		client:     client,
+		userAgent:  userAgent,
+		ttl:        _defaultTTL,
+		maxEntries: _defaultMaxEntries,
+		entries:    make(map[string]*entry),
+	}
+}
+
+func (c *Cache) WithTTL(ttl time.Duration) {
+	c.ttl = ttl
+}
+
+// WithMaxEntries bounds the number of hosts kept by the cache.
+func (c *Cache) WithMaxEntries(maxEntries int) {
+	c.maxEntries = maxEntries
+}
+
+func (c *Cache) UserAgent() string {
+	return c.userAgent
+}
+
+func (c *Cache) Allowed(ctx context.Context, userAgent, rawURL string) (bool, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false, err
+	}
+	rules := c.rules(ctx, u)
+	return rules.Allowed(c.agent(userAgent), u.RequestURI()), nil
+}
+
+// Wait blocks until the crawl delay of the host of rawURL has elapsed since
+// the previous request made through the cache.
+func (c *Cache) Wait(ctx context.Context, userAgent, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	e := c.entry(u)
+	rules := c.rules(ctx, u)
+	delay := rules.CrawlDelay(c.agent(userAgent))
+	if delay <= 0 {
+		return nil
+	}
+
+	e.mu.Lock()
+	now := time.Now()
+	start := e.next
+	if start.Before(now) {
+		start = now
+	}
+	e.next = start.Add(delay)
+	e.mu.Unlock()
+
+	wait := time.Until(start)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *Cache) agent(userAgent string) string {
+	if userAgent == "" {
+		return c.userAgent
+	}
+	return userAgent
+}
+
+func (c *Cache) entry(u *url.URL) *entry {
+	key := u.Scheme + "://" + u.Host
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[key]
+	if !ok {
+		if len(c.entries) >= c.maxEntries {
+			c.evict(time.Now())
+		}
+		e = &entry{}
+		c.entries[key] = e
+	}
+	return e
+}
+
+// evict removes the hosts whose rules expired and whose crawl delay elapsed,
+// then arbitrary hosts while the cache is still full.
+func (c *Cache) evict(now time.Time) {
+	for key, e := range c.entries {
+		if e.mu.TryLock() {
+			stale := now.Sub(e.fetchedAt) >= c.ttl && !e.next.After(now)
+			e.mu.Unlock()
+			if stale {
+				delete(c.entries, key)
+			}
+		}
+	}
+	for key := range c.entries {
+		if len(c.entries) < c.maxEntries {
+			return
+		}
+		delete(c.entries, key)
+	}
+}
+
+func (c *Cache) rules(ctx context.Context, u *url.URL) *Rules {
+	e := c.entry(u)
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if e.rules != nil && time.Since(e.fetchedAt) < c.ttl {
+		return e.rules
+	}
+	rules, ok := c.fetch(ctx, u)
+	if !ok {
+		return rules
+	}
+	e.rules = rules
+	e.fetchedAt = time.Now()
+	return e.rules
+}
+
+// fetch follows RFC 9309: a missing robots.txt allows everything and a server
+// error disallows everything. Network errors allow everything without being
+// cached, so that the real error is reported by whoever fetches the page.
+func (c *Cache) fetch(ctx context.Context, u *url.URL) (*Rules, bool) {
+	ctx, cancel := context.WithTimeout(ctx, _defaultFetchTimeout)
+	defer cancel()
+	robotsURL := url.URL{Scheme: u.Scheme, Host: u.Host, Path: "/robots.txt"}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, robotsURL.String(), nil)
+	if err != nil {
+		return AllowAll(), false
+	}
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return AllowAll(), false
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		return DisallowAll(), true
+	case resp.StatusCode >= http.StatusBadRequest:
+		return AllowAll(), true
+	}
+	rules, err := Parse(io.LimitReader(resp.Body, _maxRobotsBytes))
+	if err != nil {
+		return AllowAll(), false
+	}
+	return rules, true
+}
@@ -0,0 +1,181 @@
+package robots
+
+import (
+	"bufio"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type rule struct {
+	allow   bool
+	pattern string
+}
+
+type group struct {
+	agents     []string
+	rules      []rule
+	crawlDelay time.Duration
+}
+
+// Rules holds the parsed content of a robots.txt file, following RFC 9309
+// plus the widely supported Crawl-delay extension.
+type Rules struct {
+	groups []*group
+}
+
+func AllowAll() *Rules {
+	return &Rules{}
+}
+
+func DisallowAll() *Rules {
+	return &Rules{
+		groups: []*group{{agents: []string{"*"}, rules: []rule{{allow: false, pattern: "/"}}}},
+	}
+}
+
+func Parse(r io.Reader) (*Rules, error) {
+	rules := &Rules{}
+	var (
+		current      *group
+		readingAgent bool
+	)
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "user-agent":
+			if current == nil || !readingAgent {
+				current = &group{}
+				rules.groups = append(rules.groups, current)
+			}
+			current.agents = append(current.agents, strings.ToLower(value))
+			readingAgent = true
+		case "allow", "disallow":
+			readingAgent = false
+			if current == nil || value == "" {
+				continue
+			}
+			current.rules = append(current.rules, rule{allow: key == "allow", pattern: value})
+		case "crawl-delay":
+			readingAgent = false
+			if current == nil {
+				continue
+			}
+			seconds, err := strconv.ParseFloat(value, 64)
+			if err != nil || seconds < 0 {
+				continue
+			}
+			current.crawlDelay = time.Duration(seconds * float64(time.Second))
+		default:
+			readingAgent = false
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Allowed reports whether userAgent may fetch path, which must include the
+// query string if there is one. The most specific matching rule wins, and
+// allow wins over disallow when both are equally specific.
+func (r *Rules) Allowed(userAgent, path string) bool {
+	if path == "" {
+		path = "/"
+	}
+	if path == "/robots.txt" {
+		return true
+	}
+	var (
+		allowed = true
+		longest = -1
+	)
+	for _, g := range r.groupsFor(userAgent) {
+		for _, rl := range g.rules {
+			if !match(rl.pattern, path) {
+				continue
+			}
+			if len(rl.pattern) > longest || (len(rl.pattern) == longest && rl.allow) {
+				longest = len(rl.pattern)
+				allowed = rl.allow
+			}
+		}
+	}
+	return allowed
+}
+
+func (r *Rules) CrawlDelay(userAgent string) time.Duration {
+	var delay time.Duration
+	for _, g := range r.groupsFor(userAgent) {
+		if g.crawlDelay > delay {
+			delay = g.crawlDelay
+		}
+	}
+	return delay
+}
+
+// groupsFor returns the groups whose user agent is the product token of
+// userAgent, compared case-insensitively as a whole token, or the "*" groups
+// when none matches.
+func (r *Rules) groupsFor(userAgent string) []*group {
+	token := productToken(userAgent)
+	var matched, wildcard []*group
+	for _, g := range r.groups {
+		for _, agent := range g.agents {
+			switch {
+			case agent == "*":
+				wildcard = append(wildcard, g)
+			case token != "" && strings.EqualFold(agent, token):
+				matched = append(matched, g)
+			}
+		}
+	}
+	if len(matched) > 0 {
+		return matched
+	}
+	return wildcard
+}
+
+func productToken(userAgent string) string {
+	token, _, _ := strings.Cut(strings.TrimSpace(userAgent), "/")
+	token, _, _ = strings.Cut(token, " ")
+	return strings.ToLower(token)
+}
+
+// match reports whether path matches pattern, where "*" matches any sequence
+// of characters and a trailing "$" anchors the pattern to the end of path.
+func match(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	pattern = strings.TrimSuffix(pattern, "$")
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	if len(parts) == 1 {
+		return !anchored || path == pattern
+	}
+	pos := len(parts[0])
+	for i, part := range parts[1:] {
+		if anchored && i == len(parts)-2 {
+			return strings.HasSuffix(path[pos:], part)
+		}
+		idx := strings.Index(path[pos:], part)
+		if idx < 0 {
+			return false
+		}
+		pos += idx + len(part)
+	}
+	return true
+}
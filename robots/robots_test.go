@@ -0,0 +1,188 @@
+package robots_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/danielperaltamadriz/html-analyzer/robots"
+	"github.com/stretchr/testify/suite"
+)
+
+const robotsTxt = `
+# comment
+User-agent: *
+Disallow: /private/
+Allow: /private/public.html
+Disallow: /*.pdf$
+Disallow: /search?
+Crawl-delay: 2
+
+User-agent: html-analyzer
+User-agent: other-bot
+Disallow: /admin
+Crawl-delay: 0.5
+`
+
+type robotsTestSuite struct {
+	suite.Suite
+}
+
+func TestRobotsSuite(t *testing.T) {
+	suite.Run(t, new(robotsTestSuite))
+}
+
+func (suite *robotsTestSuite) TestAllowed() {
+	rules, err := robots.Parse(strings.NewReader(robotsTxt))
+	suite.Require().NoError(err)
+
+	testCases := []struct {
+		name      string
+		userAgent string
+		path      string
+		allowed   bool
+	}{
+		{name: "allowed path", userAgent: "some-bot", path: "/", allowed: true},
+		{name: "disallowed directory", userAgent: "some-bot", path: "/private/file.html", allowed: false},
+		{name: "most specific allow wins", userAgent: "some-bot", path: "/private/public.html", allowed: true},
+		{name: "wildcard with end anchor", userAgent: "some-bot", path: "/files/report.pdf", allowed: false},
+		{name: "end anchor does not match longer paths", userAgent: "some-bot", path: "/files/report.pdf.html", allowed: true},
+		{name: "query string", userAgent: "some-bot", path: "/search?q=1", allowed: false},
+		{name: "robots.txt is always allowed", userAgent: "some-bot", path: "/robots.txt", allowed: true},
+		{name: "specific group replaces the wildcard group", userAgent: "html-analyzer/1.0 (+https://example.com)", path: "/private/file.html", allowed: true},
+		{name: "specific group rules", userAgent: "html-analyzer/1.0", path: "/admin/users", allowed: false},
+		{name: "grouped user agents", userAgent: "Other-Bot", path: "/admin", allowed: false},
+	}
+
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			suite.Equal(tc.allowed, rules.Allowed(tc.userAgent, tc.path))
+		})
+	}
+}
+
+func (suite *robotsTestSuite) TestAllowedWholeToken() {
+	rules, err := robots.Parse(strings.NewReader("User-agent: a\nUser-agent: analyzer\nDisallow: /\n"))
+	suite.Require().NoError(err)
+
+	testCases := []struct {
+		name      string
+		userAgent string
+		allowed   bool
+	}{
+		{name: "part of the product token", userAgent: "html-analyzer/1.0", allowed: true},
+		{name: "whole product token", userAgent: "Analyzer/2.0", allowed: false},
+	}
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			suite.Equal(tc.allowed, rules.Allowed(tc.userAgent, "/"))
+		})
+	}
+}
+
+func (suite *robotsTestSuite) TestCrawlDelay() {
+	rules, err := robots.Parse(strings.NewReader(robotsTxt))
+	suite.Require().NoError(err)
+	suite.Equal(2*time.Second, rules.CrawlDelay("some-bot"))
+	suite.Equal(500*time.Millisecond, rules.CrawlDelay("html-analyzer/1.0"))
+}
+
+func (suite *robotsTestSuite) TestCache() {
+	testCases := []struct {
+		name       string
+		statusCode int
+		body       string
+		allowed    bool
+	}{
+		{name: "robots.txt disallows the path", statusCode: http.StatusOK, body: "User-agent: *\nDisallow: /page", allowed: false},
+		{name: "missing robots.txt allows everything", statusCode: http.StatusNotFound, allowed: true},
+		{name: "unreachable robots.txt disallows everything", statusCode: http.StatusServiceUnavailable, allowed: false},
+	}
+
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			var requests atomic.Int32
+			fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				suite.Equal("/robots.txt", r.URL.Path)
+				suite.Equal("html-analyzer/1.0", r.Header.Get("User-Agent"))
+				w.WriteHeader(tc.statusCode)
+				w.Write([]byte(tc.body)) // nolint: errcheck
+			}))
+			defer fakeServer.Close()
+
+			cache := robots.NewCache(nil, "html-analyzer/1.0")
+			for i := 0; i < 2; i++ {
+				allowed, err := cache.Allowed(context.Background(), "", fakeServer.URL+"/page")
+				suite.Require().NoError(err)
+				suite.Equal(tc.allowed, allowed)
+			}
+			suite.Equal(int32(1), requests.Load())
+		})
+	}
+}
+
+func (suite *robotsTestSuite) TestWait() {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("User-agent: *\nCrawl-delay: 0.05")) // nolint: errcheck
+	}))
+	defer fakeServer.Close()
+
+	cache := robots.NewCache(nil, "html-analyzer")
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		suite.Require().NoError(cache.Wait(context.Background(), "", fakeServer.URL+"/page"))
+	}
+	suite.GreaterOrEqual(time.Since(start), 100*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	suite.ErrorIs(cache.Wait(ctx, "", fakeServer.URL+"/page"), context.Canceled)
+}
+
+func (suite *robotsTestSuite) TestCacheUserAgent() {
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("User-agent: *\nDisallow: /page\n\nUser-agent: other-bot\nAllow: /")) // nolint: errcheck
+	}))
+	defer fakeServer.Close()
+
+	cache := robots.NewCache(nil, "html-analyzer/1.0")
+	testCases := []struct {
+		name      string
+		userAgent string
+		allowed   bool
+	}{
+		{name: "default user agent", userAgent: "", allowed: false},
+		{name: "user agent of the call", userAgent: "other-bot/2.0", allowed: true},
+	}
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			allowed, err := cache.Allowed(context.Background(), tc.userAgent, fakeServer.URL+"/page")
+			suite.Require().NoError(err)
+			suite.Equal(tc.allowed, allowed)
+		})
+	}
+}
+
+func (suite *robotsTestSuite) TestCacheMaxEntries() {
+	var requests atomic.Int32
+	fakeServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte("User-agent: *\nDisallow: /page")) // nolint: errcheck
+	}))
+	defer fakeServer.Close()
+	otherServer := httptest.NewServer(http.NotFoundHandler())
+	defer otherServer.Close()
+
+	cache := robots.NewCache(nil, "html-analyzer/1.0")
+	cache.WithMaxEntries(1)
+	for _, rawURL := range []string{fakeServer.URL + "/page", otherServer.URL + "/page", fakeServer.URL + "/page"} {
+		_, err := cache.Allowed(context.Background(), "", rawURL)
+		suite.Require().NoError(err)
+	}
+	suite.Equal(int32(2), requests.Load())
+}
@@ -0,0 +1,181 @@
+package sitemap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze"
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"github.com/danielperaltamadriz/html-analyzer/cache"
+	"github.com/danielperaltamadriz/html-analyzer/internal/hostlimit"
+	"github.com/danielperaltamadriz/html-analyzer/internal/safe"
+)
+
+const (
+	_defaultWorkers            = 8
+	_defaultPerHostConcurrency = 4
+)
+
+type IssueType string
+
+const (
+	IssueNon200            IssueType = "non_200"
+	IssueRedirect          IssueType = "redirect"
+	IssueCanonicalMismatch IssueType = "canonical_mismatch"
+	IssueAnalysisFailed    IssueType = "analysis_failed"
+)
+
+// Issue is a sitemap URL that should not be listed as is: it does not answer
+// with a 200, like a 404 or a 204, it redirects to Target, or its canonical is
+// Target. A page breaking the analyzer is reported as analysis_failed.
+type Issue struct {
+	URL  string
+	Type IssueType
+	// StatusCode is the status of a non_200 page, zero when it did not
+	// respond.
+	StatusCode int
+	Target     string
+	Message    string
+}
+
+type Page struct {
+	Entry
+	Details *models.HTMLDetails
+	Err     error
+}
+
+type Result struct {
+	Sitemaps      []string
+	SitemapErrors []SitemapError
+	Truncated     bool
+	Pages         []Page
+	Issues        []Issue
+}
+
+type Checker struct {
+	loader      *Loader
+	newAnalyzer func() *analyze.Analyzer
+
+	workers            int
+	perHostConcurrency int
+}
+
+func NewChecker(loader *Loader, newAnalyzer func() *analyze.Analyzer) *Checker {
+	return &Checker{
+		loader:             loader,
+		newAnalyzer:        newAnalyzer,
+		workers:            _defaultWorkers,
+		perHostConcurrency: _defaultPerHostConcurrency,
+	}
+}
+
+// WithWorkers sets the number of pages analyzed at once, across all hosts.
+func (c *Checker) WithWorkers(workers int) {
+	c.workers = workers
+}
+
+func (c *Checker) WithPerHostConcurrency(concurrency int) {
+	c.perHostConcurrency = concurrency
+}
+
+// Run analyzes every page listed by the sitemap at sitemapURL and reports the
+// issues of the listed URLs.
+func (c *Checker) Run(ctx context.Context, sitemapURL string) (*Result, error) {
+	listing, err := c.loader.Load(ctx, sitemapURL)
+	if err != nil {
+		return nil, err
+	}
+	result := Result{
+		Sitemaps:      listing.Sitemaps,
+		SitemapErrors: listing.Errors,
+		Truncated:     listing.Truncated,
+		Pages:         c.analyze(ctx, listing.Entries),
+	}
+	for _, page := range result.Pages {
+		result.Issues = append(result.Issues, issues(page)...)
+	}
+	return &result, ctx.Err()
+}
+
+func (c *Checker) analyze(ctx context.Context, entries []Entry) []Page {
+	workers := c.workers
+	if workers < 1 {
+		workers = 1
+	}
+	hosts := hostlimit.New(c.perHostConcurrency)
+	pages := make([]Page, len(entries))
+	queue := make(chan int)
+
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				pages[i] = c.analyzePage(ctx, hosts, entries[i])
+			}
+		}()
+	}
+	for i := range entries {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+	return pages
+}
+
+func (c *Checker) analyzePage(ctx context.Context, hosts *hostlimit.Limiter, entry Entry) Page {
+	release, err := hosts.Acquire(ctx, entry.Loc)
+	if err != nil {
+		return Page{Entry: entry, Err: err}
+	}
+	defer release()
+
+	analyzer := c.newAnalyzer()
+	analyzer.WithContext(ctx)
+	details, err := safe.Run(func() (*models.HTMLDetails, error) {
+		return analyzer.RunFromURL(entry.Loc)
+	})
+	return Page{Entry: entry, Details: details, Err: err}
+}
+
+func issues(page Page) []Issue {
+	if errors.Is(page.Err, safe.ErrRecovered) {
+		return []Issue{{URL: page.Loc, Type: IssueAnalysisFailed, Message: page.Err.Error()}}
+	}
+	if page.Err != nil {
+		issue := Issue{URL: page.Loc, Type: IssueNon200, Message: page.Err.Error()}
+		var e *models.Error
+		if errors.As(page.Err, &e) && e.Type == models.ErrTypeUpstreamStatus {
+			issue.StatusCode = e.ResponseStatusCode
+		}
+		return []Issue{issue}
+	}
+	var found []Issue
+	if page.Details.StatusCode != 0 && page.Details.StatusCode != http.StatusOK {
+		found = append(found, Issue{
+			URL:        page.Loc,
+			Type:       IssueNon200,
+			StatusCode: page.Details.StatusCode,
+			Message:    fmt.Sprintf("status code: %d", page.Details.StatusCode),
+		})
+	}
+	servedURL := page.Loc
+	if page.Details.FinalURL != "" {
+		servedURL = page.Details.FinalURL
+		found = append(found, Issue{URL: page.Loc, Type: IssueRedirect, Target: page.Details.FinalURL})
+	}
+	// The canonical of a redirected page is compared to the page it redirects
+	// to, the redirect being already reported.
+	if page.Details.SEO != nil {
+		for _, canonical := range page.Details.SEO.Canonicals {
+			if cache.NormalizeURL(canonical) != cache.NormalizeURL(servedURL) {
+				found = append(found, Issue{URL: page.Loc, Type: IssueCanonicalMismatch, Target: canonical})
+			}
+		}
+	}
+	return found
+}
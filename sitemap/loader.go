@@ -0,0 +1,152 @@
+package sitemap
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze"
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"github.com/danielperaltamadriz/html-analyzer/internal/neterr"
+	"github.com/danielperaltamadriz/html-analyzer/netguard"
+)
+
+const (
+	_defaultMaxURLs     = 500
+	_defaultMaxSitemaps = 50
+	_defaultTimeout     = 30 * time.Second
+)
+
+// Entry is a page listed by a sitemap.
+type Entry struct {
+	URL
+	// Sitemap is the sitemap listing the page.
+	Sitemap string
+}
+
+type SitemapError struct {
+	URL string
+	Err error
+}
+
+type Listing struct {
+	Entries []Entry
+	// Sitemaps are the sitemaps read, starting with the requested one.
+	Sitemaps []string
+	// Errors holds the sitemaps of an index that could not be read.
+	Errors []SitemapError
+	// Truncated is set when the sitemaps list more pages than the maximum.
+	Truncated bool
+}
+
+type Loader struct {
+	client      *http.Client
+	userAgent   string
+	maxURLs     int
+	maxSitemaps int
+}
+
+func NewLoader(client *http.Client) *Loader {
+	if client == nil {
+		client = netguard.New().Client(http.DefaultClient)
+	}
+	return &Loader{
+		client:      client,
+		userAgent:   analyze.DefaultUserAgent,
+		maxURLs:     _defaultMaxURLs,
+		maxSitemaps: _defaultMaxSitemaps,
+	}
+}
+
+func (l *Loader) WithUserAgent(userAgent string) {
+	l.userAgent = userAgent
+}
+
+func (l *Loader) WithMaxURLs(maxURLs int) {
+	l.maxURLs = maxURLs
+}
+
+func (l *Loader) WithMaxSitemaps(maxSitemaps int) {
+	l.maxSitemaps = maxSitemaps
+}
+
+// Load reads the sitemap at sitemapURL and, when it is a sitemap index, the
+// sitemaps it lists. The pages are listed once, in the order they are found.
+// Only the failure of the requested sitemap is returned as an error.
+func (l *Loader) Load(ctx context.Context, sitemapURL string) (*Listing, error) {
+	u, err := url.ParseRequestURI(sitemapURL)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, models.NewError(models.ErrTypeInvalidURL, "invalid url")
+	}
+
+	var listing Listing
+	seenPages := make(map[string]bool)
+	seenSitemaps := map[string]bool{u.String(): true}
+	queue := []string{u.String()}
+	for len(queue) > 0 && len(listing.Sitemaps) < l.maxSitemaps {
+		current := queue[0]
+		queue = queue[1:]
+		sitemap, err := l.fetch(ctx, current)
+		if err != nil {
+			if len(listing.Sitemaps) == 0 {
+				return nil, err
+			}
+			listing.Errors = append(listing.Errors, SitemapError{URL: current, Err: err})
+			continue
+		}
+		listing.Sitemaps = append(listing.Sitemaps, current)
+		for _, next := range sitemap.Sitemaps {
+			if !seenSitemaps[next] {
+				seenSitemaps[next] = true
+				queue = append(queue, next)
+			}
+		}
+		for _, page := range sitemap.URLs {
+			if seenPages[page.Loc] {
+				continue
+			}
+			if len(listing.Entries) >= l.maxURLs {
+				listing.Truncated = true
+				return &listing, nil
+			}
+			seenPages[page.Loc] = true
+			listing.Entries = append(listing.Entries, Entry{URL: page, Sitemap: current})
+		}
+	}
+	return &listing, nil
+}
+
+func (l *Loader) fetch(ctx context.Context, sitemapURL string) (*Sitemap, error) {
+	ctx, cancel := context.WithTimeout(ctx, _defaultTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, sitemapURL, nil)
+	if err != nil {
+		return nil, models.NewError(models.ErrTypeInvalidURL, "invalid url")
+	}
+	req.Header.Set("User-Agent", l.userAgent)
+	resp, err := l.client.Do(req)
+	if errors.Is(err, netguard.ErrBlockedAddress) {
+		return nil, models.NewError(models.ErrTypeBlockedAddress, "address not allowed")
+	}
+	if neterr.IsTimeout(err) {
+		return nil, models.NewError(models.ErrTypeTimeout, "timed out getting sitemap")
+	}
+	if err != nil {
+		return nil, models.NewError(models.ErrTypeInvalidURL, "failed to get sitemap")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, models.NewErrorWithStatusCode(models.ErrTypeUpstreamStatus, fmt.Sprintf("invalid status code: %d", resp.StatusCode), resp.StatusCode)
+	}
+	sitemap, err := Parse(resp.Body)
+	if neterr.IsTimeout(err) {
+		return nil, models.NewError(models.ErrTypeTimeout, "timed out reading sitemap")
+	}
+	if err != nil {
+		return nil, models.NewError(models.ErrTypeInvalidResponse, err.Error())
+	}
+	return sitemap, nil
+}
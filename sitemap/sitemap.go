@@ -0,0 +1,77 @@
+package sitemap
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// _maxSize is the largest uncompressed sitemap allowed by the protocol.
+const _maxSize = 50 << 20
+
+var ErrInvalidSitemap = errors.New("invalid sitemap")
+
+type URL struct {
+	Loc     string
+	LastMod string
+}
+
+// Sitemap is either a urlset, listing pages, or a sitemap index, listing
+// other sitemaps.
+type Sitemap struct {
+	URLs     []URL
+	Sitemaps []string
+}
+
+type document struct {
+	XMLName  xml.Name
+	URLs     []location `xml:"url"`
+	Sitemaps []location `xml:"sitemap"`
+}
+
+type location struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod"`
+}
+
+// Parse reads a sitemap, gunzipping it when it is compressed.
+func Parse(r io.Reader) (*Sitemap, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(2); err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidSitemap, err)
+		}
+		defer gz.Close()
+		r = gz
+	} else {
+		r = br
+	}
+
+	var doc document
+	if err := xml.NewDecoder(io.LimitReader(r, _maxSize)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidSitemap, err)
+	}
+	var sitemap Sitemap
+	switch doc.XMLName.Local {
+	case "urlset":
+		for _, u := range doc.URLs {
+			if loc := strings.TrimSpace(u.Loc); loc != "" {
+				sitemap.URLs = append(sitemap.URLs, URL{Loc: loc, LastMod: strings.TrimSpace(u.LastMod)})
+			}
+		}
+	case "sitemapindex":
+		for _, s := range doc.Sitemaps {
+			if loc := strings.TrimSpace(s.Loc); loc != "" {
+				sitemap.Sitemaps = append(sitemap.Sitemaps, loc)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("%w: unexpected root element %s", ErrInvalidSitemap, doc.XMLName.Local)
+	}
+	return &sitemap, nil
+}
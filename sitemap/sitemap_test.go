@@ -0,0 +1,236 @@
+package sitemap_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/danielperaltamadriz/html-analyzer/analyze"
+	"github.com/danielperaltamadriz/html-analyzer/analyze/models"
+	"github.com/danielperaltamadriz/html-analyzer/sitemap"
+	"github.com/stretchr/testify/suite"
+	"golang.org/x/net/html"
+)
+
+const _urlset = `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<url><loc>https://example.com/</loc><lastmod>2024-01-01</lastmod></url>
+	<url><loc> https://example.com/about </loc></url>
+	<url><loc></loc></url>
+</urlset>`
+
+const _index = `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<sitemap><loc>https://example.com/pages.xml</loc></sitemap>
+	<sitemap><loc>https://example.com/posts.xml.gz</loc></sitemap>
+</sitemapindex>`
+
+type sitemapTestSuite struct {
+	suite.Suite
+	server *httptest.Server
+	site   map[string]string
+}
+
+func TestSitemapSuite(t *testing.T) {
+	suite.Run(t, new(sitemapTestSuite))
+}
+
+func (suite *sitemapTestSuite) SetupTest() {
+	suite.site = make(map[string]string)
+	suite.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/moved":
+			http.Redirect(w, r, "/ok", http.StatusMovedPermanently)
+			return
+		case "/non-authoritative":
+			w.Header().Set("Content-Type", "text/html")
+			w.WriteHeader(http.StatusNonAuthoritativeInfo)
+			w.Write([]byte(`<html><head><title>copy</title></head></html>`)) // nolint: errcheck
+			return
+		}
+		body, ok := suite.site[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if strings.HasSuffix(r.URL.Path, ".gz") {
+			w.Header().Set("Content-Type", "application/gzip")
+			w.Write(gzipped(body)) // nolint: errcheck
+			return
+		}
+		w.Write([]byte(strings.ReplaceAll(body, "{{server}}", suite.server.URL))) // nolint: errcheck
+	}))
+}
+
+func (suite *sitemapTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *sitemapTestSuite) TestParse() {
+	testCases := []struct {
+		name string
+		body []byte
+
+		expected      *sitemap.Sitemap
+		expectedError bool
+	}{
+		{
+			name: "urlset",
+			body: []byte(_urlset),
+			expected: &sitemap.Sitemap{URLs: []sitemap.URL{
+				{Loc: "https://example.com/", LastMod: "2024-01-01"},
+				{Loc: "https://example.com/about"},
+			}},
+		},
+		{
+			name: "sitemap index",
+			body: []byte(_index),
+			expected: &sitemap.Sitemap{Sitemaps: []string{
+				"https://example.com/pages.xml",
+				"https://example.com/posts.xml.gz",
+			}},
+		},
+		{
+			name: "gzipped urlset",
+			body: gzipped(_urlset),
+			expected: &sitemap.Sitemap{URLs: []sitemap.URL{
+				{Loc: "https://example.com/", LastMod: "2024-01-01"},
+				{Loc: "https://example.com/about"},
+			}},
+		},
+		{
+			name:          "unexpected root element",
+			body:          []byte(`<html><body></body></html>`),
+			expectedError: true,
+		},
+		{
+			name:          "invalid xml",
+			body:          []byte(`not a sitemap`),
+			expectedError: true,
+		},
+	}
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			parsed, err := sitemap.Parse(bytes.NewReader(tc.body))
+			if tc.expectedError {
+				suite.ErrorIs(err, sitemap.ErrInvalidSitemap)
+				return
+			}
+			suite.Require().NoError(err)
+			suite.Equal(tc.expected, parsed)
+		})
+	}
+}
+
+func (suite *sitemapTestSuite) TestLoad() {
+	suite.site["/sitemap.xml"] = `<sitemapindex>
+		<sitemap><loc>{{server}}/pages.xml</loc></sitemap>
+		<sitemap><loc>{{server}}/posts.xml.gz</loc></sitemap>
+		<sitemap><loc>{{server}}/missing.xml</loc></sitemap>
+		<sitemap><loc>{{server}}/pages.xml</loc></sitemap>
+	</sitemapindex>`
+	suite.site["/pages.xml"] = `<urlset><url><loc>{{server}}/a</loc></url><url><loc>{{server}}/b</loc></url></urlset>`
+	// The gzipped sitemap is served as is, so it lists absolute URLs.
+	suite.site["/posts.xml.gz"] = `<urlset><url><loc>https://example.com/post</loc></url><url><loc>https://example.com/post</loc></url></urlset>`
+
+	testCases := []struct {
+		name    string
+		maxURLs int
+
+		expectedURLs      []string
+		expectedTruncated bool
+	}{
+		{
+			name:         "read every sitemap of the index",
+			maxURLs:      10,
+			expectedURLs: []string{suite.server.URL + "/a", suite.server.URL + "/b", "https://example.com/post"},
+		},
+		{
+			name:              "stop at the maximum number of urls",
+			maxURLs:           2,
+			expectedURLs:      []string{suite.server.URL + "/a", suite.server.URL + "/b"},
+			expectedTruncated: true,
+		},
+	}
+	for _, tc := range testCases {
+		suite.Run(tc.name, func() {
+			loader := sitemap.NewLoader(http.DefaultClient)
+			loader.WithMaxURLs(tc.maxURLs)
+			listing, err := loader.Load(context.Background(), suite.server.URL+"/sitemap.xml")
+			suite.Require().NoError(err)
+
+			urls := make([]string, 0, len(listing.Entries))
+			for _, entry := range listing.Entries {
+				urls = append(urls, entry.Loc)
+			}
+			suite.Equal(tc.expectedURLs, urls)
+			suite.Equal(tc.expectedTruncated, listing.Truncated)
+		})
+	}
+
+	loader := sitemap.NewLoader(http.DefaultClient)
+	listing, err := loader.Load(context.Background(), suite.server.URL+"/sitemap.xml")
+	suite.Require().NoError(err)
+	suite.Equal([]string{
+		suite.server.URL + "/sitemap.xml",
+		suite.server.URL + "/pages.xml",
+		suite.server.URL + "/posts.xml.gz",
+	}, listing.Sitemaps)
+	suite.Require().Len(listing.Errors, 1)
+	suite.Equal(suite.server.URL+"/missing.xml", listing.Errors[0].URL)
+
+	_, err = loader.Load(context.Background(), suite.server.URL+"/missing.xml")
+	var e *models.Error
+	suite.Require().ErrorAs(err, &e)
+	suite.Equal(models.ErrTypeUpstreamStatus, e.Type)
+	suite.Equal(http.StatusNotFound, e.ResponseStatusCode)
+}
+
+func (suite *sitemapTestSuite) TestChecker() {
+	suite.site["/sitemap.xml"] = `<urlset>
+		<url><loc>{{server}}/ok</loc></url>
+		<url><loc>{{server}}/missing</loc></url>
+		<url><loc>{{server}}/moved</loc></url>
+		<url><loc>{{server}}/duplicate</loc></url>
+		<url><loc>{{server}}/non-authoritative</loc></url>
+		<url><loc>{{server}}/broken</loc></url>
+	</urlset>`
+	suite.site["/ok"] = `<html><head><link rel="canonical" href="{{server}}/ok"></head></html>`
+	suite.site["/duplicate"] = `<html><head><link rel="canonical" href="/ok"></head></html>`
+	suite.site["/broken"] = `<html><body><x-panic></x-panic></body></html>`
+
+	checker := sitemap.NewChecker(sitemap.NewLoader(http.DefaultClient), func() *analyze.Analyzer {
+		analyzer := analyze.NewAnalyzer()
+		analyzer.WithSearchManyElements(analyzer.SEO, func(n *html.Node) bool {
+			if n.Type == html.ElementNode && n.Data == "x-panic" {
+				panic("unexpected page")
+			}
+			return false
+		})
+		return analyzer
+	})
+	checker.WithWorkers(2)
+	result, err := checker.Run(context.Background(), suite.server.URL+"/sitemap.xml")
+	suite.Require().NoError(err)
+
+	suite.Len(result.Pages, 6)
+	suite.Equal([]sitemap.Issue{
+		{URL: suite.server.URL + "/missing", Type: sitemap.IssueNon200, StatusCode: http.StatusNotFound, Message: "invalid status code: 404"},
+		{URL: suite.server.URL + "/moved", Type: sitemap.IssueRedirect, Target: suite.server.URL + "/ok"},
+		{URL: suite.server.URL + "/duplicate", Type: sitemap.IssueCanonicalMismatch, Target: suite.server.URL + "/ok"},
+		{URL: suite.server.URL + "/non-authoritative", Type: sitemap.IssueNon200, StatusCode: http.StatusNonAuthoritativeInfo, Message: "status code: 203"},
+		{URL: suite.server.URL + "/broken", Type: sitemap.IssueAnalysisFailed, Message: "failed to analyze page"},
+	}, result.Issues)
+}
+
+func gzipped(body string) []byte {
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	gz.Write([]byte(body)) // nolint: errcheck
+	gz.Close()
+	return buf.Bytes()
+}